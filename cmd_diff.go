@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"pgdump_splitter/dbobject"
+)
+
+func runDiff(argv []string) int {
+
+	var format string
+
+	fs := newFlagSet("diff", "[flags] directory1 directory2")
+	fs.StringVar(&format, "format", "text", "Output format of differences. text - lines prefixed by - (only in directory1), + (only in directory2) and ~ (changed). json")
+
+	if code, ok := parseFlags(fs, argv); !ok {
+		return code
+	}
+
+	if code, ok := validateDiffFormat(fs, format); !ok {
+		return code
+	}
+
+	if fs.NArg() != 2 {
+		return usageError(fs, "expected exactly two directories")
+	}
+
+	diff, err := dbobject.DiffTrees(fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		return failure("diff", err)
+	}
+
+	return printDiff("diff", diff, format)
+}
+
+// Checks output format of differences, shared by diff and verify commands
+func validateDiffFormat(fs *flag.FlagSet, format string) (int, bool) {
+
+	if !(format == "text" || format == "json") {
+		return usageError(fs, "invalid value passed to `format` modifier: %s", format), false
+	}
+
+	return exitOk, true
+}
+
+// Prints differences and returns exit code reflecting them
+func printDiff(name string, diff *dbobject.TreeDiff, format string) int {
+
+	if err := diff.Print(os.Stdout, format); err != nil {
+		return failure(name, err)
+	}
+
+	if !diff.Empty() {
+		return exitDifferences
+	}
+
+	return exitOk
+}
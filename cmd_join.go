@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"pgdump_splitter/dbobject"
+)
+
+func runJoin(argv []string) int {
+
+	var dir, out string
+
+	fs := newFlagSet("join", "[flags] directory")
+	fs.StringVar(&out, "o", "-", "File the script is written to. Use - for stdout")
+
+	if code, ok := parseFlags(fs, argv); !ok {
+		return code
+	}
+
+	if fs.NArg() != 1 {
+		return usageError(fs, "expected exactly one directory")
+	}
+
+	dir = fs.Arg(0)
+
+	f := os.Stdout
+	if out != "-" {
+
+		var err error
+		if f, err = os.Create(out); err != nil {
+			return failure("join", err)
+		}
+		defer f.Close()
+	}
+
+	w := bufio.NewWriter(f)
+
+	if err := dbobject.JoinTree(dir, w); err != nil {
+		return failure("join", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return failure("join", err)
+	}
+
+	return exitOk
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"pgdump_splitter/dbobject"
+	"pgdump_splitter/output"
+)
+
+// Lists objects of the dump without writing any file
+func runLs(argv []string) int {
+
+	var args dbobject.Config
+	var format string
+
+	fs := newFlagSet("ls", "[flags]")
+	fs.StringVar(&args.File, "f", "", "path to dump generated by pg_dump or pg_dumpall. If omited the program will expect data on stdin via system pipe.")
+	fs.StringVar(&args.ExDb, "blacklist-db", "^(template|postgres)", "Regular expression pattern allowing to skip listing of matching databases")
+	fs.StringVar(&args.WlDb, "whitelist-db", "", "Regular expression pattern allowing to whitelist databases. If set, only databases matching this expression will be listed")
+	fs.StringVar(&args.ExOT, "exclude-objects", "", "Regular expression pattern allowing to skip listing of matching database objects. The expression is matched against TYPE value found in the dumped SQL")
+	fs.IntVar(&args.BufS, "buffer", 1024*1024, "Set up maximum buffer sizze if your dump contains data not feeting the scanner")
+	fs.StringVar(&format, "format", "table", "Output format. table - one object per line with its line number, database, schema, type, name and owner. tree - objects grouped by databases and schemas. json")
+	lf := addLogFlags(fs)
+
+	if code, ok := parseFlags(fs, argv); !ok {
+		return code
+	}
+
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected argument %q", fs.Arg(0))
+	}
+
+	if !(format == "table" || format == "tree" || format == "json") {
+		return usageError(fs, "invalid value passed to `format` modifier: %s", format)
+	}
+
+	if err := lf.setup(fs, false); err != nil {
+		return usageError(fs, "%s", err.Error())
+	}
+
+	// the listing is the only output
+	output.Quiet = true
+
+	listing, err := dbobject.ListDump(&args)
+	if err != nil {
+		return failure("ls", err)
+	}
+
+	if err := listing.Print(os.Stdout, format); err != nil {
+		return failure("ls", err)
+	}
+
+	return exitOk
+}
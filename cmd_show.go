@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"pgdump_splitter/dbobject"
+	"pgdump_splitter/output"
+)
+
+// Prints a single object of the dump, together with objects stored along with it in custom mode
+func runShow(argv []string) int {
+
+	var args dbobject.Config
+
+	fs := newFlagSet("show", "[flags] schema.object|schema.function(argument types)")
+	fs.StringVar(&args.File, "f", "", "path to dump generated by pg_dump or pg_dumpall. If omited the program will expect data on stdin via system pipe.")
+	fs.StringVar(&args.ExDb, "blacklist-db", "^(template|postgres)", "Regular expression pattern allowing to skip searching of matching databases")
+	fs.StringVar(&args.WlDb, "whitelist-db", "", "Regular expression pattern allowing to whitelist databases. If set, the object is searched for only in databases matching this expression")
+	fs.StringVar(&args.ExOT, "exclude-objects", "", "Regular expression pattern allowing to skip matching database objects, ie ^ACL$. The expression is matched against TYPE value found in the dumped SQL")
+	fs.IntVar(&args.BufS, "buffer", 1024*1024, "Set up maximum buffer sizze if your dump contains data not feeting the scanner")
+	fs.BoolVar(&args.KeepPasswords, "keep-passwords", false, "Keeps password values found in options of user mappings. By default they are replaced by '********'")
+	lf := addLogFlags(fs)
+
+	if code, ok := parseFlags(fs, argv); !ok {
+		return code
+	}
+
+	if fs.NArg() != 1 {
+		return usageError(fs, "expected exactly one object identifier")
+	}
+
+	if err := lf.setup(fs, false); err != nil {
+		return usageError(fs, "%s", err.Error())
+	}
+
+	// the object is the only output
+	output.Quiet = true
+
+	w := bufio.NewWriter(os.Stdout)
+
+	if err := dbobject.ShowDump(&args, fs.Arg(0), w); err != nil {
+		return failure("show", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return failure("show", err)
+	}
+
+	return exitOk
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"pgdump_splitter/dbobject"
+	"pgdump_splitter/output"
+	"time"
+)
+
+// Registers flags configuring processing of the dump. They are shared by split and verify commands
+func addSplitFlags(fs *flag.FlagSet, args *dbobject.Config) {
+
+	fs.StringVar(&args.File, "f", "", "path to dump generated by pg_dump or pg_dumpall. If omited the program will expect data on stdin via system pipe.")
+	fs.StringVar(&args.Mode, "mode", "custom", "The mode of dumping db objects. origin - for file organization as present in the database dump. custom - reorganizes db objects storing related ones into single file")
+	fs.StringVar(&args.Dest, "dst", "structure", "Location where structures will be dumped to")
+	fs.BoolVar(&args.NoDb, "ndb", false, "No db name in destination path. It should not be set to true if multiple databases are dumped at once")
+	fs.StringVar(&args.ExDb, "blacklist-db", "^(template|postgres)", "Regular expression pattern allowing to skip extraction of matching databases. Usefull in case of processing dump files. In case of using a pipe from pg_dumpall, exclude them using pd_dumpall switch.")
+	fs.StringVar(&args.WlDb, "whitelist-db", "", "Regular expression pattern allowing to whitelist databases. If set, only databases matching this expression will be processed")
+	fs.BoolVar(&args.MvRl, "mc", false, "Move dump of roles into each database subdirectory")
+	fs.StringVar(&args.McLink, "mc-link", "copy", "How roles are placed into database subdirectories by -mc. copy - files are copied. symlink - relative symbolic links to files in {dst}/-/ (implies -mc-keep). hardlink - hard links")
+	fs.BoolVar(&args.McKeep, "mc-keep", false, "Keeps {dst}/-/ directory with roles as the canonical copy, when they are moved into database subdirectories by -mc")
+	fs.StringVar(&args.McRoles, "mc-roles", "all", "Roles moved into database subdirectories by -mc. all - every role of the cluster. used - only roles referenced by objects of the database (owners, grants, default privileges, policies, user mappings) and roles they are members of")
+	fs.IntVar(&args.BufS, "buffer", 1024*1024, "Set up maximum buffer sizze if your dump contains data not feeting the scanner")
+	fs.BoolVar(&args.Cln, "clean", false, "If true, it will wipe out the content of the destination directory. Otherwise will attempt to add new files")
+	fs.BoolVar(&args.Quiet, "quiet", false, "If true, no information is outputed to std out")
+	fs.BoolVar(&args.AclFiles, "aclfiles", false, "Applicable or mode=custom only. Makes GRANTs to be outputed to separate files suffixed with .acl.sql, ie table_name.acl.sql. Otherwise acls are appended to related object file.")
+	fs.StringVar(&args.ExOT, "exclude-objects", "", "Regular expression pattern allowing to skip extraction of matching database objects. The expression is matched against TYPE value found in the dumped SQL")
+	fs.StringVar(&args.Restrict, "restrict", "", "Restrict hash that supports restricted mode introduced in postgresql 17.6. Without this option every restrict/unrestrict line will be skipped")
+	fs.StringVar(&args.PathConflicts, "path-conflicts", "rename", "How to handle generated paths which are too long or collide on case-insensitive filesystems. rename - disambiguate names by a hash suffix. error - stop processing with an error")
+	fs.StringVar(&args.Normalize, "normalize", "", "Comma separated list of normalization profiles applied to dumped objects. owner - strips ALTER ... OWNER TO statements. tablespace - strips SET default_tablespace statements. privileges - skips GRANTs and REVOKEs (ACLs)")
+	fs.StringVar(&args.RoleMap, "role-map", "", "Comma separated list of role renames applied to dumped objects, ie app_prod=app,app_stage=app. Allows comparing structures dumped from different environments")
+	fs.StringVar(&args.Header, "header", "", "Adds a comment with provenance metadata on top of every created file. full - source database, server and pg_dump versions, dump time and splitter version. stable - only source database and major server version, so the header doesn't change between dumps")
+	fs.StringVar(&args.Prologue, "prologue", "none", "Handling of SET statements found at the beginning of every database dump. none - skips them. file - writes them to {database}/_prologue.sql. prepend - writes the file and prepends the statements to every created file")
+	fs.BoolVar(&args.DumpInfo, "dump-info", false, "Writes metadata of every dumped database (server and pg_dump versions, dump time, client encoding, standard_conforming_strings, search_path) to {database}/_dump_info.json")
+	fs.BoolVar(&args.DryRun, "dry-run", false, "Processes the dump without writing any file. Prints files which would be created or appended to, with types of objects stored in them, and files which would be removed by -clean")
+	fs.StringVar(&args.DryRunFormat, "dry-run-format", "text", "Output format of -dry-run. text or json")
+	fs.BoolVar(&args.Stats, "stats", false, "Prints summary of the run: processed and skipped databases, objects per type and schema, excluded objects, written files and bytes, read lines and elapsed time")
+	fs.StringVar(&args.StatsJson, "stats-json", "", "Writes summary of the run in json format to the given file. Use - for stdout")
+	fs.StringVar(&args.Progress, "progress", "auto", "Progress reporting to stderr. auto - enabled if stderr is a terminal and -quiet is not set. tty - continuously updated line. log - periodic machine readable lines, suitable for CI logs. off - disabled")
+	fs.DurationVar(&args.ProgressInterval, "progress-interval", 10*time.Second, "Interval of progress lines printed in -progress=log mode")
+	fs.StringVar(&args.Partitions, "partitions", "separate", "Applicable for mode=custom only. Handling of table partitions. separate - partitions are stored as any other table. parent - partitions, their indexes, constraints and ATTACH PARTITION statements are appended to the file of the parent table. companion - the same, but into {parent}.partitions.sql file next to the parent table file")
+	fs.StringVar(&args.PartitionCollapse, "partition-collapse", "", "Applicable for mode=custom only. Regular expression matched against partition names. Of partitions of the same parent matching the expression, only the first one is stored as a representative")
+	fs.StringVar(&args.TenantSchemas, "tenant-schemas", "", "Regular expression matching names of tenant schemas, ie ^tenant_[0-9]+$. Schemas of identical content (with the schema name replaced by a placeholder) are stored once, as the reference schema with a list of member schemas in _members.txt. Deviating schemas are stored separately")
+	fs.StringVar(&args.TenantReference, "tenant-reference", "", "Name of the reference schema for -tenant-schemas. The default is the first matching schema found in the dump")
+	fs.BoolVar(&args.SkipMatviewData, "skip-matview-data", false, "Skips REFRESH MATERIALIZED VIEW statements (MATERIALIZED VIEW DATA entries). Otherwise, in custom mode, they are appended to the materialized view file")
+	fs.StringVar(&args.TypeDirs, "type-dirs", "spaces", "Naming of directories of object types. spaces - names of types as they are dumped, ie MATERIALIZED VIEW (lowercase in custom mode). underscores - spaces are replaced by underscores, ie MATERIALIZED_VIEW (materialized_view in custom mode)")
+	fs.BoolVar(&args.KeepPasswords, "keep-passwords", false, "Keeps password values found in options of user mappings. By default they are replaced by '********'")
+	fs.StringVar(&args.Unclassified, "unclassified", "warn", "Handling of content which can't be stored into a file of an object: lines found outside of any object, objects without a path and objects with names which can't be parsed. warn - reports them with line numbers as warnings (objects with a path are stored there anyway). file - additionally writes them to {database}/_unclassified.sql instead")
+	fs.BoolVar(&args.Strict, "strict", false, "Stops processing with an error when an object of unknown type is found. Otherwise unknown types are only reported as warnings")
+}
+
+// Checks values of the split flags, which are not validated while processing
+func validateSplitFlags(fs *flag.FlagSet, args *dbobject.Config) (int, bool) {
+
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected argument %q", fs.Arg(0)), false
+	}
+
+	if !(args.Mode == "" || args.Mode == "custom" || args.Mode == "origin") {
+		return usageError(fs, "invalid value passed to `mode` modifier: %s", args.Mode), false
+	}
+
+	return exitOk, true
+}
+
+func runSplit(argv []string) int {
+
+	var args dbobject.Config
+
+	fs := newFlagSet("split", "[flags]")
+	addSplitFlags(fs, &args)
+	lf := addLogFlags(fs)
+	fs.Bool("version", false, "Show program version")
+
+	if code, ok := parseFlags(fs, argv); !ok {
+		return code
+	}
+
+	if isFlagPassed(fs, "version") {
+		fmt.Printf("pgdump_splitter %s\n", version)
+		return exitOk
+	}
+
+	if code, ok := validateSplitFlags(fs, &args); !ok {
+		return code
+	}
+
+	// json output must not be mixed with other messages
+	output.Quiet = args.Quiet || (args.DryRun && args.DryRunFormat == "json") || args.StatsJson == "-"
+	args.Version = version
+
+	if err := lf.setup(fs, args.Quiet); err != nil {
+		return usageError(fs, "%s", err.Error())
+	}
+
+	if err := dbobject.StartProcessing(&args); err != nil {
+		return failure("split", err)
+	}
+
+	// Print the output
+	output.Println("Finished")
+
+	return exitOk
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"pgdump_splitter/dbobject"
+	"pgdump_splitter/output"
+)
+
+// Splits the dump into a temporary directory and compares the result with the existing structure
+func runVerify(argv []string) int {
+
+	var args dbobject.Config
+	var format string
+
+	fs := newFlagSet("verify", "[flags]")
+	addSplitFlags(fs, &args)
+	lf := addLogFlags(fs)
+	fs.StringVar(&format, "format", "text", "Output format of differences. text - lines prefixed by - (only in the fresh split), + (only in -dst) and ~ (changed). json")
+
+	if code, ok := parseFlags(fs, argv); !ok {
+		return code
+	}
+
+	if code, ok := validateSplitFlags(fs, &args); !ok {
+		return code
+	}
+
+	if code, ok := validateDiffFormat(fs, format); !ok {
+		return code
+	}
+
+	if args.DryRun {
+		return usageError(fs, "-dry-run is not applicable")
+	}
+
+	if err := lf.setup(fs, true); err != nil {
+		return usageError(fs, "%s", err.Error())
+	}
+
+	tmp, err := os.MkdirTemp("", "pgdump_splitter_verify")
+	if err != nil {
+		return failure("verify", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	expected := args.Dest
+	args.Dest = tmp
+	args.Cln = false
+	args.Version = version
+	output.Quiet = true
+
+	if err := dbobject.StartProcessing(&args); err != nil {
+		return failure("verify", err)
+	}
+
+	diff, err := dbobject.DiffTrees(tmp, expected)
+	if err != nil {
+		return failure("verify", err)
+	}
+
+	return printDiff("verify", diff, format)
+}
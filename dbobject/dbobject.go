@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	fu "pgdump_splitter/fileutils"
+	"pgdump_splitter/output"
 	"regexp"
 	"strings"
 )
@@ -15,16 +16,21 @@ var rgx_normalize_index *regexp.Regexp
 var rgx_normalize_subtypes_a *regexp.Regexp
 var rgx_normalize_subtypes_b *regexp.Regexp
 var rgx_normalize_subtypes2 *regexp.Regexp
+var rgx_qualified_name *regexp.Regexp
 var rgx_genFunctionName *regexp.Regexp
 var rgx_fncNormArgNames_b *regexp.Regexp
 var rgx_fncNormArgNames_c *regexp.Regexp
 
+// identifier, either plain or quoted
+const rgxIdent = `("(?:[^"]|"")+"|[\w$]+)`
+
 func init() {
 
-	rgx_normalize_index = regexp.MustCompile(` ON ([\w]+)\.([\w]+)`)
+	rgx_normalize_index = regexp.MustCompile(` ON (?:ONLY )?` + rgxIdent + `\.` + rgxIdent)
 	rgx_normalize_subtypes_a = regexp.MustCompile(`^([A-Z ]+) (.*)$`)
-	rgx_normalize_subtypes_b = regexp.MustCompile(`^([\S]+)\.([\S]+)$`)
+	rgx_normalize_subtypes_b = regexp.MustCompile(`^` + rgxIdent + `\.` + rgxIdent + `$`)
 	rgx_normalize_subtypes2 = regexp.MustCompile(`^(.*) (.*)$`)
+	rgx_qualified_name = regexp.MustCompile(rgxIdent + `\.` + rgxIdent)
 	rgx_genFunctionName = regexp.MustCompile(`^((FUNCTION|PROCEDURE) )?(.*)\((.*)\)$`)
 
 	rgx_fncNormArgNames_b = regexp.MustCompile(`.*( DEFAULT.*)$`)
@@ -38,6 +44,8 @@ type DbObjPath struct {
 	FullPath    string
 	NoDbInPath  bool
 	IsCustom    bool
+	// suffix of a companion file, which is stored next to the file of the related object, ie `orders.partitions.sql`
+	Companion string
 }
 
 type DbObject struct {
@@ -46,10 +54,28 @@ type DbObject struct {
 	ObjType    string
 	ObjSubtype string
 	ObjSubName string
+	Owner      string
 	Content    strings.Builder
 	Database   string
 	AclFiles   bool
 	Paths      DbObjPath
+	Line       int
+
+	// header of a section of pg_dumpall globals (ie `Roles`), which is split into objects when stored
+	section string
+	// line numbers of the lines of the section content
+	sectionLines []int
+}
+
+// Returns the database the object belongs to.
+// DATABASE objects are dumped before their database is connected, so they belong to the database of their name
+func (obj *DbObject) ownDatabase() string {
+
+	if obj.ObjType == "DATABASE" {
+		return obj.Name
+	}
+
+	return obj.Database
 }
 
 func (obj *DbObject) init(aclfiles bool) {
@@ -64,43 +90,96 @@ func (obj *DbObject) appendContent(line *string) {
 // It makes some minor formatting (mainly adds/removes EOLs)
 func (obj *DbObject) StoreObj() error {
 
+	if err := checkObjType(obj); err != nil {
+		return err
+	}
+
 	obj.normalizeDbObject()
+
+	return obj.storeNormalized()
+}
+
+// Called with every object written into a file and the path of the file, set by tests checking that no object gets lost
+var storing func(obj *DbObject, path string)
+
+// Stores the object, which has already been normalized
+func (obj *DbObject) storeNormalized() error {
+
 	obj.generateDestinationPath()
 
+	if reason := obj.unclassifiedReason(); reason != "" {
+		if written, err := unclassified.storeObject(obj, reason); written || err != nil {
+			return err
+		}
+	}
+
 	if obj.Paths.FullPath == "" {
-		//		fmt.Println("Emtpy path")
-		//		fmt.Println(obj.Content)
 		return nil
 	}
 
-	newlycreated, err := fu.CreateFile(obj.Paths.FullPath)
+	fullpath, err := resolveOutputPath(obj.Paths.Rootpath, obj.Paths.FullPath)
 	if err != nil {
-		return fmt.Errorf("Could not create the file:" + obj.Paths.FullPath)
+		return err
 	}
+	obj.Paths.FullPath = fullpath
+
+	rolesUsage.recordRoleFile(obj)
+
+	output.Log.Debug("store object", "line", obj.Line, "type", obj.ObjType, "name", obj.Name, "path", obj.Paths.FullPath)
+
+	if storing != nil {
+		storing(obj, obj.Paths.FullPath)
+	}
+
+	return appendOutputFile(obj.Paths.FullPath, obj.Database, obj.fileContent(), obj.ObjType)
+}
 
-	newfile, err := os.OpenFile(obj.Paths.FullPath, os.O_APPEND|os.O_WRONLY|os.O_APPEND, 0770)
+// Appends the text to the file, creating the whole path needed for that file.
+// Newly created files start with the header and the prologue of the database, if requested.
+// In dry run, the write is only planned.
+func appendOutputFile(path string, dbname string, text string, what string) error {
+
+	if dryrun != nil {
+		dryrun.planWrite(path, what, false)
+		return nil
+	}
+
+	newlycreated, err := fu.CreateFile(path)
+	if err != nil {
+		return fmt.Errorf("Could not create the file:" + path)
+	}
+
+	newfile, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_APPEND, 0770)
 
 	if err != nil {
-		return fmt.Errorf("Could not open the file:" + obj.Paths.FullPath)
+		return fmt.Errorf("Could not open the file:" + path)
 	}
 	defer newfile.Close()
 
 	var prefix string
 	if !newlycreated {
 		prefix = "\n"
+	} else {
+		prefix = dumpinfo.filePreamble(dbname)
 	}
 
-	content := obj.Content.String()
-	_, err = newfile.WriteString(prefix + strings.Trim(content, " -\n") + "\n")
+	written, err := newfile.WriteString(prefix + text)
 
 	if err != nil {
-		return fmt.Errorf("Could not write text to:" + obj.Paths.FullPath)
+		return fmt.Errorf("Could not write text to:" + path)
 	}
 
+	stats.addWrite(path, newlycreated, written)
+
 	return nil
 
 }
 
+// Returns content of the object the way it's written to the file, without leading and trailing empty comment lines
+func (obj *DbObject) fileContent() string {
+	return strings.Trim(obj.Content.String(), " -\n") + "\n"
+}
+
 // In some cases pgdump generates function identifiers containing argument names, incl OUT keyword
 // this function stripes unwanted parts our from the identifier.
 // Note, it's naive, condidering the input string is in requested format.
@@ -143,6 +222,15 @@ func getFuncIdentParts(funcident string) (string, string) {
 // It applies to indexes, triggers and similar objects which have no parent object type stored in object name
 func (dbo *DbObject) normalizeSubtypes2(newtype string) error {
 
+	if newtype == "TABLE" {
+		if parent := dbo.parentRelation(); parent != "" {
+			dbo.Name = dbo.Name[len(parent)+1:]
+			dbo.ObjSubName = parent
+			dbo.ObjSubtype = newtype
+			return nil
+		}
+	}
+
 	matches := rgx_normalize_subtypes2.FindStringSubmatch(dbo.Name)
 
 	if len(matches) > 0 {
@@ -154,6 +242,19 @@ func (dbo *DbObject) normalizeSubtypes2(newtype string) error {
 	return nil
 }
 
+// Returns the name of the relation found in the content of the object, which the name of the object starts with.
+// Names are not quoted in the name of the object (ie `Order Lines Order Lines_pkey`), so they can't be split at a space.
+func (dbo *DbObject) parentRelation() string {
+
+	for _, m := range rgx_qualified_name.FindAllStringSubmatch(dbo.Content.String(), -1) {
+		if rel := unquoteIdent(m[2]); strings.HasPrefix(dbo.Name, rel+" ") {
+			return rel
+		}
+	}
+
+	return ""
+}
+
 // Modifies meta information of object, of some of their data are stored name of the object
 // It applies to comments or ACLs
 func (dbo *DbObject) normalizeSubtypes() error {
@@ -175,6 +276,24 @@ func (dbo *DbObject) normalizeSubtypes() error {
 		}
 	}
 
+	// Names are quoted the same way as in statements, ie `TABLE "Order Lines"`
+	quoted := dbo.ObjSubName
+	dbo.ObjSubName = unquoteIdent(quoted)
+
+	// Acls of servers are named `FOREIGN SERVER server`
+	if dbo.Paths.IsCustom && dbo.ObjSubtype == "FOREIGN SERVER" {
+		dbo.ObjSubtype = "SERVER"
+	}
+
+	// Comments on policies and rules, ie `POLICY p_tenant ON orders`, go to the file of the table (or view)
+	if dbo.Paths.IsCustom && (dbo.ObjSubtype == "POLICY" || dbo.ObjSubtype == "RULE") {
+
+		if i := strings.LastIndex(quoted, " ON "); i >= 0 {
+			dbo.ObjSubName = unquoteIdent(quoted[i+len(" ON "):])
+			dbo.ObjSubtype = relationType(dbo, dbo.ObjSubName)
+		}
+	}
+
 	return nil
 }
 
@@ -184,7 +303,7 @@ func (dbo *DbObject) normalizeIndex() error {
 
 	if len(matches) > 0 {
 		dbo.ObjSubtype = "TABLE"
-		dbo.ObjSubName = matches[2]
+		dbo.ObjSubName = unquoteIdent(matches[2])
 	}
 
 	return nil
@@ -192,13 +311,18 @@ func (dbo *DbObject) normalizeIndex() error {
 
 // prepares object type-based part of the file path
 // In `origin` mode it leaves names untouched
-// In `custom` mode it makes names lowercase
+// In `custom` mode it makes names lowercase and replaces spaces by underscores
+// Types come from the dump, so they are escaped the same way as names of objects
 func generateObjTypePath(typename string, iscustom bool) string {
 
+	if typeDirsUnderscored {
+		typename = strings.ReplaceAll(typename, " ", "_")
+	}
+
 	if iscustom {
-		return strings.ToLower(typename)
+		return EscapePathComponent(strings.ToLower(typename))
 	} else {
-		return typename
+		return EscapePathComponent(typename)
 	}
 }
 
@@ -223,7 +347,11 @@ func (dbo *DbObject) generateDestinationPath() {
 
 		fname, args := getFuncIdentParts(dbo.Name)
 		args = NormalizeFunctionIdentArgs(args)
-		dbo.Name = fname + "(" + args + ")"
+
+		// names which can't be parsed are kept for reporting
+		if fname != "" {
+			dbo.Name = fname + "(" + args + ")"
+		}
 		name = generateFuncFilename(fname, args)
 	}
 
@@ -255,17 +383,20 @@ func generateFuncFilename(fname string, args string) string {
 func (dbo *DbObject) generateDestinationPathOrigin() {
 
 	var dbpath string
-	if dbo.Database != "" && !dbo.Paths.NoDbInPath {
-		dbpath = dbo.Database
+	if dbo.ownDatabase() != "" && !dbo.Paths.NoDbInPath {
+		dbpath = dbo.ownDatabase()
 	}
 
+	dbpath = EscapePathComponent(dbpath)
+	filename := EscapePathComponent(dbo.Paths.NameForFile)
+
 	if dbo.ObjType == "SCHEMA" || dbo.ObjSubtype == "SCHEMA" {
-		dbo.Paths.FullPath = filepath.Join(dbo.Paths.Rootpath, dbpath, dbo.Paths.NameForFile, dbo.Paths.NameForFile) + ".sql"
+		dbo.Paths.FullPath = filepath.Join(dbo.Paths.Rootpath, dbpath, filename, filename) + ".sql"
 
 	} else {
 
 		objtpename := generateObjTypePath(dbo.ObjType, dbo.Paths.IsCustom)
-		dbo.Paths.FullPath = filepath.Join(dbo.Paths.Rootpath, dbpath, dbo.Schema, objtpename, dbo.Paths.NameForFile) + ".sql"
+		dbo.Paths.FullPath = filepath.Join(dbo.Paths.Rootpath, dbpath, EscapePathComponent(dbo.Schema), objtpename, filename) + ".sql"
 	}
 
 }
@@ -281,12 +412,12 @@ func (dbo *DbObject) generateDestinationPathCustom() {
 		suffix = ".acl" + suffix
 	}
 
-	if dbo.Database != "" && !dbo.Paths.NoDbInPath {
-		dbpath = dbo.Database
+	if dbo.Paths.Companion != "" {
+		suffix = "." + dbo.Paths.Companion + suffix
 	}
 
-	if dbo.ObjType == "DATABASE" {
-		dbpath = dbo.Name
+	if dbo.ownDatabase() != "" && !dbo.Paths.NoDbInPath {
+		dbpath = dbo.ownDatabase()
 	}
 
 	if dbo.ObjType == "DEFAULT ACL" {
@@ -299,14 +430,25 @@ func (dbo *DbObject) generateDestinationPathCustom() {
 		path_objtype = "SEQUENCE"
 	}
 
+	dbpath = EscapePathComponent(dbpath)
+	filename := EscapePathComponent(dbo.Paths.NameForFile)
+
+	if path_objsubtype == "" {
+		path_objsubtype = path_objtype
+	}
+
 	if dbo.ObjType == "SCHEMA" || dbo.ObjSubtype == "SCHEMA" {
-		dbo.Paths.FullPath = filepath.Join(dbo.Paths.Rootpath, dbpath, dbo.Paths.NameForFile, dbo.Paths.NameForFile) + suffix
+		dbo.Paths.FullPath = filepath.Join(dbo.Paths.Rootpath, dbpath, filename, filename) + suffix
+	} else if section := databaseSectionPath(path_objsubtype); section != "" {
+		dbo.Paths.FullPath = filepath.Join(dbo.Paths.Rootpath, dbpath, section, filename) + suffix
 	} else {
 
+		schema := EscapePathComponent(dbo.Schema)
+
 		if dbo.ObjSubtype == "" {
-			dbo.Paths.FullPath = filepath.Join(dbo.Paths.Rootpath, dbpath, dbo.Schema, generateObjTypePath(path_objtype, dbo.Paths.IsCustom), dbo.Paths.NameForFile) + suffix
+			dbo.Paths.FullPath = filepath.Join(dbo.Paths.Rootpath, dbpath, schema, generateObjTypePath(path_objtype, dbo.Paths.IsCustom), filename) + suffix
 		} else {
-			dbo.Paths.FullPath = filepath.Join(dbo.Paths.Rootpath, dbpath, dbo.Schema, generateObjTypePath(path_objsubtype, dbo.Paths.IsCustom), dbo.Paths.NameForFile) + suffix
+			dbo.Paths.FullPath = filepath.Join(dbo.Paths.Rootpath, dbpath, schema, generateObjTypePath(path_objsubtype, dbo.Paths.IsCustom), filename) + suffix
 		}
 
 	}
@@ -323,37 +465,17 @@ func (dbo *DbObject) normalizeDbObject() error {
 			return nil
 		}
 	*/
-	switch dbo.ObjType {
-	case "COMMENT":
-		err = dbo.normalizeSubtypes()
-	case "ACL":
+	info := objTypes[dbo.ObjType]
+
+	switch info.grouping {
+	case groupNamePrefixed:
 		err = dbo.normalizeSubtypes()
-	case "FK CONSTRAINT":
-		err = dbo.normalizeSubtypes2("TABLE")
-	case "CHECK CONSTRAINT":
-		err = dbo.normalizeSubtypes2("TABLE")
-	case "CONSTRAINT":
-		err = dbo.normalizeSubtypes2("TABLE")
-	case "TRIGGER":
-		err = dbo.normalizeSubtypes2("TABLE")
-	case "INDEX":
+	case groupParentName:
+		err = dbo.normalizeSubtypes2(info.parent)
+	case groupIndex:
 		err = dbo.normalizeIndex()
-	case "DEFAULT":
-		err = dbo.normalizeSubtypes2("TABLE")
-	case "SEQUENCE SET":
-		dbo.ObjSubtype = "SEQUENCE"
-		dbo.ObjSubName = dbo.Name
-		err = dbo.normalizeSubtypes2("SEQUENCE")
-	case "SEQUENCE OWNED BY":
-		err = dbo.normalizeSubtypes2("SEQUENCE")
-	case "DATABASE PROPERTIES":
-		dbo.ObjSubtype = "DATABASE"
-		dbo.ObjSubName = dbo.Name
-	case "PUBLICATION TABLE":
-		if dbo.Paths.IsCustom {
-			dbo.Schema = "-"
-		}
-		err = dbo.normalizeSubtypes2("PUBLICATION")
+	case groupSpecial:
+		err = dbo.normalizeSpecial(info)
 	}
 
 	if err != nil {
@@ -365,6 +487,57 @@ func (dbo *DbObject) normalizeDbObject() error {
 		dbo.Name = dbo.ObjSubName
 	}
 
+	output.Log.Debug("normalize object", "line", dbo.Line, "type", dbo.ObjType, "name", dbo.Name, "schema", dbo.Schema,
+		"subtype", dbo.ObjSubtype, "subname", dbo.ObjSubName)
+
+	return nil
+}
+
+// Normalizes objects of types, which don't follow any common grouping pattern
+func (dbo *DbObject) normalizeSpecial(info objTypeInfo) error {
+
+	switch dbo.ObjType {
+	case "SEQUENCE SET":
+		dbo.ObjSubtype = info.parent
+		dbo.ObjSubName = dbo.Name
+		return dbo.normalizeSubtypes2(info.parent)
+	case "DATABASE PROPERTIES":
+		dbo.ObjSubtype = info.parent
+		dbo.ObjSubName = dbo.Name
+	case "PUBLICATION TABLE":
+		if dbo.Paths.IsCustom {
+			dbo.Schema = "-"
+		}
+		return dbo.normalizeSubtypes2(info.parent)
+	case "MATERIALIZED VIEW DATA":
+		// In custom mode REFRESH MATERIALIZED VIEW is appended to the view definition
+		if dbo.Paths.IsCustom {
+			dbo.ObjSubtype = info.parent
+			dbo.ObjSubName = dbo.Name
+		}
+	case "USER MAPPING":
+		// In custom mode it's stored along with the server. Name of the object is `USER MAPPING role SERVER server`
+		if dbo.Paths.IsCustom {
+			if i := strings.LastIndex(dbo.Name, " SERVER "); i >= 0 {
+				dbo.ObjSubtype = info.parent
+				dbo.ObjSubName = dbo.Name[i+len(" SERVER "):]
+			}
+		}
+	case "ROW SECURITY":
+		// In custom mode it's stored along with the table. Name of the object is the name of the table
+		if dbo.Paths.IsCustom {
+			dbo.ObjSubtype = info.parent
+			dbo.ObjSubName = dbo.Name
+		}
+	case "POLICY", "RULE":
+		// In custom mode they're stored along with the table (or view). Name of the object is `table policy`
+		if dbo.Paths.IsCustom {
+			err := dbo.normalizeSubtypes2(info.parent)
+			dbo.ObjSubtype = relationType(dbo, dbo.ObjSubName)
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -1,5 +1,7 @@
 package dbobject
 
+import "time"
+
 // Structure handling program runtime configuration.
 // Values are comming from command line arguments.
 type Config struct {
@@ -17,4 +19,33 @@ type Config struct {
 	Quiet    bool
 	AclFiles bool
 	Restrict string
+
+	PathConflicts string
+	Normalize     string
+	RoleMap       string
+	Header        string
+	Prologue      string
+	DumpInfo      bool
+	DryRun        bool
+	DryRunFormat  string
+	Stats         bool
+	StatsJson     string
+	Version       string
+	Strict        bool
+	Unclassified  string
+
+	SkipMatviewData bool
+	TypeDirs        string
+	KeepPasswords   bool
+	McRoles         string
+	McLink          string
+	McKeep          bool
+
+	Partitions        string
+	PartitionCollapse string
+	TenantSchemas     string
+	TenantReference   string
+
+	Progress         string
+	ProgressInterval time.Duration
 }
@@ -0,0 +1,72 @@
+package dbobject
+
+import "strings"
+
+// Objects of the current database held back until the database is complete.
+// Some groupings (ie partitions with their parent table, identical tenant schemas) can only be decided when all objects of the database are known.
+var deferred []*DbObject
+
+// Tells whether objects are held back instead of being stored immediately
+func deferringObjects() bool {
+	return partitions != nil || tenants != nil
+}
+
+// Keeps a copy of the object until the end of the database
+func deferObject(dbo *DbObject) {
+
+	clone := *dbo
+	clone.Content = strings.Builder{}
+	clone.Content.WriteString(dbo.Content.String())
+
+	deferred = append(deferred, &clone)
+}
+
+// Stores objects held back for the database, after they've been regrouped
+func flushDeferred() error {
+
+	if len(deferred) == 0 {
+		return nil
+	}
+
+	objs := deferred
+	deferred = nil
+
+	for _, dbo := range objs {
+
+		if err := checkObjType(dbo); err != nil {
+			return err
+		}
+
+		dbo.normalizeDbObject()
+	}
+
+	partitions.analyze(objs)
+
+	kept := objs[:0]
+
+	for _, dbo := range objs {
+
+		if !partitions.regroup(dbo) {
+			excludeObject(dbo, "partition-collapse")
+			continue
+		}
+
+		kept = append(kept, dbo)
+	}
+
+	kept, err := tenants.collapse(kept)
+	if err != nil {
+		return err
+	}
+
+	for _, dbo := range kept {
+
+		stats.addObject(dbo)
+
+		if err := dbo.storeNormalized(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
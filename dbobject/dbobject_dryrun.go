@@ -0,0 +1,195 @@
+package dbobject
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	fu "pgdump_splitter/fileutils"
+	"strings"
+)
+
+// File operation planned by the dry run
+type PlannedFile struct {
+	Path        string   `json:"path"`
+	Action      string   `json:"action"`
+	ObjectTypes []string `json:"object_types"`
+}
+
+// Result of the dry run.
+// It records file operations instead of performing them.
+type DryRunPlan struct {
+	Files  []*PlannedFile `json:"files"`
+	Remove []string       `json:"remove"`
+
+	clean bool
+	index map[string]*PlannedFile
+}
+
+// Plan of currently running dry run. Nil if files are really written
+var dryrun *DryRunPlan
+
+func initDryRun(args *Config) error {
+
+	dryrun = nil
+
+	if !args.DryRun {
+		return nil
+	}
+
+	switch args.DryRunFormat {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("invalid value of dry run format: %s", args.DryRunFormat)
+	}
+
+	dryrun = &DryRunPlan{Files: []*PlannedFile{}, Remove: []string{}, clean: args.Cln, index: make(map[string]*PlannedFile)}
+
+	if args.Cln {
+		return dryrun.planWipe(args.Dest)
+	}
+
+	return nil
+}
+
+// Records files, which would be removed from the destination directory by -clean option
+func (plan *DryRunPlan) planWipe(dir string) error {
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			plan.Remove = append(plan.Remove, path)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// Records writing of an object of given type to the file
+func (plan *DryRunPlan) planWrite(path string, objtype string, overwrite bool) {
+
+	pf, found := plan.index[path]
+
+	if !found {
+
+		pf = &PlannedFile{Path: path, Action: "create", ObjectTypes: []string{}}
+
+		if overwrite {
+			pf.Action = "write"
+		} else if _, err := os.Stat(path); err == nil && !plan.clean {
+			pf.Action = "append"
+		}
+
+		plan.index[path] = pf
+		plan.Files = append(plan.Files, pf)
+	}
+
+	for _, t := range pf.ObjectTypes {
+		if t == objtype {
+			return
+		}
+	}
+
+	pf.ObjectTypes = append(pf.ObjectTypes, objtype)
+}
+
+// Records copying of planned files, for which keep function returns true, from one directory to another
+func (plan *DryRunPlan) planCopyDir(src string, dest string, keep func(path string) bool) {
+
+	prefix := src + string(filepath.Separator)
+
+	for _, pf := range plan.Files {
+
+		if !strings.HasPrefix(pf.Path, prefix) || !keep(pf.Path) {
+			continue
+		}
+
+		for _, t := range pf.ObjectTypes {
+			plan.planWrite(filepath.Join(dest, strings.TrimPrefix(pf.Path, prefix)), t, false)
+		}
+	}
+}
+
+// Records renaming of the planned file or directory
+func (plan *DryRunPlan) planMove(from string, to string) {
+
+	for _, pf := range plan.Files {
+		if moved, ok := movedPath(pf.Path, from, to); ok {
+			delete(plan.index, pf.Path)
+			pf.Path = moved
+			plan.index[moved] = pf
+		}
+	}
+}
+
+// Drops planned files from the directory, which is going to be removed
+func (plan *DryRunPlan) planRemoveDir(dir string) {
+
+	prefix := dir + string(filepath.Separator)
+	files := plan.Files[:0]
+
+	for _, pf := range plan.Files {
+		if strings.HasPrefix(pf.Path, prefix) {
+			delete(plan.index, pf.Path)
+		} else {
+			files = append(files, pf)
+		}
+	}
+
+	plan.Files = files
+}
+
+// Prints the plan either as a human readable text or as a json document
+func (plan *DryRunPlan) Print(w io.Writer, format string) error {
+
+	if format == "json" {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	for _, path := range plan.Remove {
+		fmt.Fprintf(w, "remove  %s\n", path)
+	}
+
+	for _, pf := range plan.Files {
+		fmt.Fprintf(w, "%-7s %s [%s]\n", pf.Action, pf.Path, strings.Join(pf.ObjectTypes, ", "))
+	}
+
+	return nil
+}
+
+// Writes data to the file, overwriting its previous content.
+// In dry run, the operation is only recorded.
+func writeOutputFile(path string, data []byte, what string) error {
+
+	if dryrun != nil {
+		dryrun.planWrite(path, what, true)
+		return nil
+	}
+
+	_, err := os.Stat(path)
+	created := os.IsNotExist(err)
+
+	if err := fu.WriteFile(path, data); err != nil {
+		return err
+	}
+
+	stats.addWrite(path, created, len(data))
+
+	return nil
+}
@@ -0,0 +1,337 @@
+package dbobject
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var rgx_dumpedFrom *regexp.Regexp
+var rgx_dumpedBy *regexp.Regexp
+var rgx_startedOn *regexp.Regexp
+var rgx_clientEncoding *regexp.Regexp
+var rgx_stdStrings *regexp.Regexp
+var rgx_searchPath *regexp.Regexp
+var rgx_prologue *regexp.Regexp
+var rgx_versionNumbers *regexp.Regexp
+
+func init() {
+
+	rgx_dumpedFrom = regexp.MustCompile(`^-- Dumped from database version (.*?)[\s]*$`)
+	rgx_dumpedBy = regexp.MustCompile(`^-- Dumped by (pg_dump|pg_dumpall) version (.*?)[\s]*$`)
+	rgx_startedOn = regexp.MustCompile(`^-- Started on (.*?)[\s]*$`)
+	rgx_clientEncoding = regexp.MustCompile(`^SET client_encoding = '(.*)';[\s]*$`)
+	rgx_stdStrings = regexp.MustCompile(`^SET standard_conforming_strings = (.*);[\s]*$`)
+	rgx_searchPath = regexp.MustCompile(`^SELECT pg_catalog\.set_config\('search_path', '(.*)', false\);[\s]*$`)
+	rgx_prologue = regexp.MustCompile(`^(SET .*|SELECT pg_catalog\.set_config\(.*\));[\s]*$`)
+	rgx_versionNumbers = regexp.MustCompile(`^(\d+)(?:\.(\d+))?`)
+}
+
+// Name of the file the metadata are written to, placed in the database directory
+const dumpInfoFileName = "_dump_info.json"
+
+// Name of the file the database prologue (SET statements preceding the first object) is written to
+const prologueFileName = "_prologue.sql"
+
+// Metadata of a dumped database, extracted from the preamble written by pg_dump
+type DumpInfo struct {
+	Database                  string  `json:"database"`
+	ServerVersion             string  `json:"server_version,omitempty"`
+	PgDumpVersion             string  `json:"pg_dump_version,omitempty"`
+	StartedOn                 string  `json:"started_on,omitempty"`
+	ClientEncoding            string  `json:"client_encoding,omitempty"`
+	StandardConformingStrings string  `json:"standard_conforming_strings,omitempty"`
+	SearchPath                *string `json:"search_path,omitempty"`
+
+	// Statements of the preamble, which set up the session before the objects are restored
+	prologue []string
+
+	// Set for metadata of a database dump (as opposite to the cluster part of pg_dumpall)
+	isdb bool
+	// Set once the \connect line of the database is found
+	bound bool
+}
+
+// Metadata of currently processed database
+var dumpinfo *DumpInfo
+
+// Header mode and version of the splitter, used for file headers
+var headerMode string
+var splitterVersion string
+
+// Prologue mode: `file`, `prepend` or `none`
+var prologueMode string
+
+// If set, metadata of every database are written to its directory
+var writeDumpInfo bool
+
+func initDumpInfo(args *Config) error {
+
+	switch args.Header {
+	case "", "full", "stable":
+		headerMode = args.Header
+	default:
+		return fmt.Errorf("invalid value of header mode: %s", args.Header)
+	}
+
+	switch args.Prologue {
+	case "file", "prepend", "none":
+		prologueMode = args.Prologue
+	case "":
+		prologueMode = "none"
+	default:
+		return fmt.Errorf("invalid value of prologue mode: %s", args.Prologue)
+	}
+
+	writeDumpInfo = args.DumpInfo
+
+	splitterVersion = args.Version
+	dumpinfo = &DumpInfo{}
+
+	return nil
+}
+
+// Starts collecting metadata of a new database dump
+func startDumpInfo(dbname string) {
+	dumpinfo = &DumpInfo{Database: dbname, isdb: true}
+}
+
+// Writes collected metadata of the database to its directory
+// and stops collecting them until next database starts
+func finishDumpInfo(args *Config) error {
+
+	if dumpinfo == nil || !dumpinfo.isdb {
+		return nil
+	}
+
+	di := dumpinfo
+	dumpinfo = nil
+
+	if di.Database != "" && !enableCurrentDb(di.Database) {
+		return nil
+	}
+
+	var dbpath string
+	if !args.NoDb {
+		dbpath = EscapePathComponent(di.Database)
+	}
+
+	if writeDumpInfo {
+
+		data, err := json.MarshalIndent(di, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		path, err := resolveOutputPath(args.Dest, filepath.Join(args.Dest, dbpath, dumpInfoFileName))
+		if err != nil {
+			return err
+		}
+
+		if err := writeOutputFile(path, append(data, '\n'), "DUMP INFO"); err != nil {
+			return err
+		}
+	}
+
+	if prologueMode == "none" || len(di.prologue) == 0 {
+		return nil
+	}
+
+	path, err := resolveOutputPath(args.Dest, filepath.Join(args.Dest, dbpath, prologueFileName))
+	if err != nil {
+		return err
+	}
+
+	return writeOutputFile(path, []byte(di.prologueText()), "PROLOGUE")
+}
+
+// Returns statements of the preamble as SQL text
+func (di *DumpInfo) prologueText() string {
+
+	if di == nil || len(di.prologue) == 0 {
+		return ""
+	}
+
+	return strings.Join(di.prologue, "\n") + "\n"
+}
+
+// Assigns the database name given by \connect line to collected metadata.
+// Database dumps created with --create option are connected after the preamble has been read.
+func bindDumpInfo(dbname string) {
+
+	if dumpinfo == nil || dumpinfo.bound {
+		return
+	}
+
+	dumpinfo.Database = dbname
+	dumpinfo.bound = true
+
+	// the preamble is repeated once connected to the created database
+	dumpinfo.prologue = nil
+}
+
+// Extracts metadata from the line, if it contains any.
+// Returns true if the line has been recognized
+func (di *DumpInfo) parseLine(line *string) bool {
+
+	if di.isdb && rgx_prologue.MatchString(*line) {
+		di.prologue = append(di.prologue, strings.TrimRight(*line, "\r\n"))
+	}
+
+	if matches := rgx_dumpedFrom.FindStringSubmatch(*line); len(matches) > 0 {
+		di.ServerVersion = matches[1]
+		return true
+	}
+
+	if matches := rgx_dumpedBy.FindStringSubmatch(*line); len(matches) > 0 {
+		di.PgDumpVersion = matches[2]
+		return true
+	}
+
+	if matches := rgx_startedOn.FindStringSubmatch(*line); len(matches) > 0 {
+		di.StartedOn = matches[1]
+		return true
+	}
+
+	if matches := rgx_clientEncoding.FindStringSubmatch(*line); len(matches) > 0 {
+		di.ClientEncoding = matches[1]
+		return true
+	}
+
+	if matches := rgx_stdStrings.FindStringSubmatch(*line); len(matches) > 0 {
+		di.StandardConformingStrings = matches[1]
+		return true
+	}
+
+	if matches := rgx_searchPath.FindStringSubmatch(*line); len(matches) > 0 {
+		di.SearchPath = &matches[1]
+		return true
+	}
+
+	return false
+}
+
+// Returns the leading numbers of the version string, ie 16 and 2 for `16.2 (Debian 16.2-1.pgdg120+2)`, 18 and 0 for `18rc1`.
+// Zero is returned for missing minor number, false if the version doesn't start with a number
+func versionNumbers(version string) (int, int, bool) {
+
+	matches := rgx_versionNumbers.FindStringSubmatch(version)
+	if len(matches) == 0 {
+		return 0, 0, false
+	}
+
+	major, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	minor, _ := strconv.Atoi(matches[2])
+
+	return major, minor, true
+}
+
+// Decides whether the dump might contain \restrict and \unrestrict commands.
+// They have been introduced in 17.6 and backpatched to minor releases of older supported versions.
+// If the version of pg_dump is not known (yet) or can't be parsed, it's assumed the commands might be present.
+func (di *DumpInfo) supportsRestrict() bool {
+
+	if di == nil || di.PgDumpVersion == "" {
+		return true
+	}
+
+	major, minor, ok := versionNumbers(di.PgDumpVersion)
+
+	switch {
+	case !ok || major >= 18:
+		return true
+	case major == 17:
+		return minor >= 6
+	case major == 16:
+		return minor >= 10
+	case major == 15:
+		return minor >= 14
+	case major == 14:
+		return minor >= 19
+	case major == 13:
+		return minor >= 22
+	}
+
+	return false
+}
+
+// Returns major version from the version string, ie 16 for `16.2 (Debian 16.2-1.pgdg120+2)`
+func majorVersion(version string) string {
+
+	version, _, _ = strings.Cut(version, " ")
+	parts := strings.Split(version, ".")
+
+	// versions prior to 10 used two numbers for the major version
+	if num, err := strconv.Atoi(parts[0]); err == nil && num < 10 && len(parts) > 1 {
+		return parts[0] + "." + parts[1]
+	}
+
+	return parts[0]
+}
+
+// Generates text placed on top of every newly created file: the header comment and the prologue, if requested
+func (di *DumpInfo) filePreamble(dbname string) string {
+
+	preamble := di.fileHeader(dbname)
+
+	if prologueMode == "prepend" && di != nil && di.isdb && len(di.prologue) > 0 {
+		preamble += di.prologueText() + "\n"
+	}
+
+	return preamble
+}
+
+// Generates comment placed on top of every newly created file.
+// In `stable` mode, only values which don't change between consecutive dumps of the same database are included.
+func (di *DumpInfo) fileHeader(dbname string) string {
+
+	if headerMode == "" {
+		return ""
+	}
+
+	if di != nil && di.Database != "" {
+		dbname = di.Database
+	}
+
+	var lines []string
+
+	if dbname != "" && dbname != "-" {
+		lines = append(lines, "-- Source database: "+dbname)
+	}
+
+	if di != nil && di.ServerVersion != "" {
+		if headerMode == "stable" {
+			lines = append(lines, "-- Dumped from database version "+majorVersion(di.ServerVersion))
+		} else {
+			lines = append(lines, "-- Dumped from database version "+di.ServerVersion)
+		}
+	}
+
+	if headerMode == "full" {
+
+		if di != nil && di.PgDumpVersion != "" {
+			lines = append(lines, "-- Dumped by pg_dump version "+di.PgDumpVersion)
+		}
+
+		if di != nil && di.StartedOn != "" {
+			lines = append(lines, "-- Started on "+di.StartedOn)
+		}
+
+		if splitterVersion != "" {
+			lines = append(lines, "-- Split by pgdump_splitter "+splitterVersion)
+		}
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "--\n" + strings.Join(lines, "\n") + "\n--\n\n"
+}
@@ -0,0 +1,48 @@
+package dbobject
+
+import (
+	"path/filepath"
+	"regexp"
+)
+
+// Mask replacing password values of user mappings
+const redactedPassword = "'********'"
+
+var rgx_passwordOption *regexp.Regexp
+
+func init() {
+	rgx_passwordOption = regexp.MustCompile(`(?i)((?:^|[\s,(])"?password"?\s+)'(?:[^']|'')*'`)
+}
+
+// If set, passwords found in options of user mappings are stored as dumped
+var keepPasswords bool
+
+// Replaces values of password options of user mappings by a mask
+func (dbo *DbObject) redactPasswords() {
+
+	if keepPasswords || dbo.ObjType != "USER MAPPING" {
+		return
+	}
+
+	content := dbo.Content.String()
+	redacted := rgx_passwordOption.ReplaceAllString(content, "${1}"+redactedPassword)
+
+	if redacted != content {
+		dbo.Content.Reset()
+		dbo.Content.WriteString(redacted)
+	}
+}
+
+// Returns directory (relative to the database) of extensions and foreign data objects in custom mode.
+// Such objects don't belong to any schema. Returns empty string for other types.
+func databaseSectionPath(objtype string) string {
+
+	switch objtype {
+	case "EXTENSION":
+		return "_extensions"
+	case "FOREIGN DATA WRAPPER", "SERVER":
+		return filepath.Join("_foreign", generateObjTypePath(objtype, true))
+	}
+
+	return ""
+}
@@ -0,0 +1,101 @@
+package dbobject
+
+import (
+	"strings"
+	"testing"
+)
+
+// Part of the plain dump created by pg_dump 16 from a database using foreign data
+const testDumpForeign = `--
+-- Name: postgres_fdw; Type: EXTENSION; Schema: -; Owner: -
+--
+
+CREATE EXTENSION IF NOT EXISTS postgres_fdw WITH SCHEMA public;
+
+
+--
+-- Name: EXTENSION postgres_fdw; Type: COMMENT; Schema: -; Owner: 
+--
+
+COMMENT ON EXTENSION postgres_fdw IS 'foreign-data wrapper for remote PostgreSQL servers';
+
+
+--
+-- Name: dblink_fdw; Type: FOREIGN DATA WRAPPER; Schema: -; Owner: postgres
+--
+
+CREATE FOREIGN DATA WRAPPER dblink_fdw VALIDATOR public.dblink_fdw_validator;
+
+
+--
+-- Name: remote; Type: SERVER; Schema: -; Owner: app
+--
+
+CREATE SERVER remote FOREIGN DATA WRAPPER postgres_fdw OPTIONS (
+    dbname 'shop',
+    host 'db.example.com'
+);
+
+
+ALTER SERVER remote OWNER TO app;
+
+--
+-- Name: USER MAPPING app SERVER remote; Type: USER MAPPING; Schema: -; Owner: app
+--
+
+CREATE USER MAPPING FOR app SERVER remote OPTIONS (
+    password 'it''s secret',
+    "user" 'app'
+);
+
+
+--
+-- Name: FOREIGN SERVER remote; Type: ACL; Schema: -; Owner: app
+--
+
+GRANT ALL ON FOREIGN SERVER remote TO app_ro;
+
+
+--
+-- PostgreSQL database dump complete
+--
+
+`
+
+func TestForeignLayout(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom"}, testDumpForeign))
+
+	if len(tree) != 3 {
+		t.Errorf("expected extension, wrapper and server files, got: %v", tree)
+	}
+
+	if ext := tree["_extensions/postgres_fdw.sql"]; !strings.Contains(ext, "COMMENT ON EXTENSION postgres_fdw") {
+		t.Errorf("comment should be appended to the extension, got: %s", ext)
+	}
+
+	if _, found := tree["_foreign/foreign data wrapper/dblink_fdw.sql"]; !found {
+		t.Errorf("foreign data wrapper file not found, got: %v", tree)
+	}
+
+	server := tree["_foreign/server/remote.sql"]
+
+	for _, want := range []string{"CREATE SERVER remote", "CREATE USER MAPPING FOR app SERVER remote", "password '********',", `"user" 'app'`, "GRANT ALL ON FOREIGN SERVER remote"} {
+		if !strings.Contains(server, want) {
+			t.Errorf("server file does not contain %s, got: %s", want, server)
+		}
+	}
+
+	if strings.Contains(server, "secret") {
+		t.Errorf("password should be redacted, got: %s", server)
+	}
+}
+
+func TestForeignKeepPasswords(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "origin", KeepPasswords: true}, testDumpForeign))
+
+	if mapping := tree["-/USER MAPPING/USER MAPPING app SERVER remote.sql"]; !strings.Contains(mapping, "password 'it''s secret'") {
+		t.Errorf("password should be kept, got: %v", tree)
+	}
+}
@@ -0,0 +1,236 @@
+package dbobject
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"pgdump_splitter/output"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// Adds dumps of the testdata corpus and the dumps of other tests as seeds
+func addDumpSeeds(f *testing.F, add func(dump string)) {
+
+	dumps, _ := filepath.Glob(filepath.Join("testdata", "dumps", "*.sql"))
+
+	for _, dump := range dumps {
+		if content, err := os.ReadFile(dump); err == nil {
+			add(string(content))
+		}
+	}
+
+	for _, dump := range []string{testDumpCreate, testDumpRoles, testDumpMatview, testDumpForeign, testDumpPartitions, testDumpFunctions} {
+		add(dump)
+	}
+}
+
+// Checks that the path is located under the root directory
+func isUnderRoot(root string, path string) bool {
+
+	rel, err := filepath.Rel(root, path)
+
+	return err == nil && rel != "." && !filepath.IsAbs(rel) && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Returns lines of the dump carrying content, which is expected in the written files.
+// Empty lines, comments, psql meta-commands and session settings of the prologue are not,
+// neither are rows of table data, which aren't split.
+func contentLines(dump string) []string {
+
+	var lines []string
+	data := false
+
+	for _, line := range strings.SplitAfter(dump, "\n") {
+
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "\\connect ") {
+			data = false
+		}
+
+		if strings.HasPrefix(line, "-- Name: ") || strings.HasPrefix(line, "-- Data for Name: ") {
+			data = strings.Contains(line, "; Type: TABLE DATA;")
+		}
+
+		if data || trimmed == "" || strings.HasPrefix(trimmed, "--") || strings.HasPrefix(trimmed, "\\") || rgx_prologue.MatchString(line) {
+			continue
+		}
+
+		// files are trimmed of dashes at the edges of their content
+		lines = append(lines, strings.Trim(trimmed, " -"))
+	}
+
+	return lines
+}
+
+func FuzzProcessStream(f *testing.F) {
+
+	addDumpSeeds(f, func(dump string) {
+		f.Add(dump, true, false)
+		f.Add(dump, false, true)
+	})
+
+	f.Fuzz(func(t *testing.T, dump string, custom bool, mc bool) {
+
+		defaultLog := output.Log
+		defer func() { output.Log = defaultLog }()
+		output.SetupLog(io.Discard, "error", "text")
+
+		// objects passed to be written, with their content at that moment
+		type storedObject struct {
+			line    int
+			path    string
+			content string
+		}
+
+		var stored []storedObject
+
+		storing = func(obj *DbObject, path string) {
+			stored = append(stored, storedObject{obj.Line, path, obj.fileContent()})
+		}
+		defer func() { storing = nil }()
+
+		mode := "origin"
+		if custom {
+			mode = "custom"
+		}
+
+		parent := t.TempDir()
+		root := filepath.Join(parent, "root")
+		args := Config{Mode: mode, Dest: root, MvRl: mc, BufS: 64 * 1024, Progress: "off", KeepPasswords: true, Unclassified: "file"}
+
+		scanner := bufio.NewScanner(strings.NewReader(dump))
+		scanner.Split(preserveNewlines)
+		scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), args.BufS)
+
+		// errors (ie too long lines) are valid results, files written until then are checked anyway
+		err := ProcessStream(&args, scanner)
+
+		entries, _ := os.ReadDir(parent)
+		for _, e := range entries {
+			if e.Name() != "root" {
+				t.Fatalf("file written outside of the destination directory: %s", e.Name())
+			}
+		}
+
+		var written strings.Builder
+
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode()&os.ModeSymlink != 0 {
+				t.Errorf("unexpected symbolic link %s", path)
+			}
+			// names taken from the dump are escaped to valid utf8
+			if !utf8.ValidString(path) {
+				t.Errorf("file name of invalid utf8 %q", path)
+			}
+			if err == nil && info.Mode().IsRegular() {
+				content, _ := os.ReadFile(path)
+				written.Write(content)
+			}
+			return nil
+		})
+
+		// every object passed to be stored is written into a file under the destination directory,
+		// its own or the file of unclassified content
+		for _, obj := range stored {
+
+			if !isUnderRoot(root, obj.path) {
+				t.Fatalf("object of line %d stored outside of the destination directory: %s", obj.line, obj.path)
+			}
+
+			// colliding paths written before might have been renamed since
+			path := pathres.current(obj.path)
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("object of line %d not written to %s: %s", obj.line, path, err)
+			}
+
+			if !strings.Contains(string(content), obj.content) {
+				t.Fatalf("file %s does not contain the object of line %d: %q", path, obj.line, obj.content)
+			}
+		}
+
+		// no exclusion filter is set, so every line of content gets into a file, its object's or the file of unclassified content
+		if err == nil {
+			for _, line := range contentLines(dump) {
+				if !strings.Contains(written.String(), line) {
+					t.Fatalf("line of the dump not written to any file: %q", line)
+				}
+			}
+		}
+	})
+}
+
+func FuzzNormalizeFunctionIdentArgs(f *testing.F) {
+
+	for _, args := range []string{
+		"",
+		"integer",
+		"p_invoice integer, p_currency character varying",
+		"IN p_id integer, OUT p_total numeric",
+		"VARIADIC p_values integer[]",
+		"p_from timestamp with time zone, p_to timestamp without time zone",
+		"p_currency character varying DEFAULT 'EUR'::character varying",
+		`"Amount" double precision, bit varying`,
+	} {
+		f.Add(args)
+	}
+
+	f.Fuzz(func(t *testing.T, args string) {
+
+		normalized := NormalizeFunctionIdentArgs(args)
+
+		// names of function files are generated from normalized arguments, which might be normalized again (ie by show)
+		if again := NormalizeFunctionIdentArgs(normalized); again != normalized {
+			t.Fatalf("normalization of %q is not stable: %q, then %q", args, normalized, again)
+		}
+	})
+}
+
+func FuzzGenerateDestinationPath(f *testing.F) {
+
+	for objtype := range objTypes {
+		f.Add("shop", "billing", objtype, "invoice", true, false)
+		f.Add("shop", "billing", objtype, "TABLE invoice", false, true)
+	}
+
+	for _, name := range []string{"total(p_invoice integer)", "FUNCTION total(integer)", "invoice invoice_pkey", "COLUMN invoice.total", "..", ".", "a/../../b", "CON", ""} {
+		f.Add("..", "..", "TABLE", name, true, true)
+		f.Add("", "", "ACL", name, false, false)
+	}
+
+	f.Fuzz(func(t *testing.T, database string, schema string, objtype string, name string, custom bool, aclfiles bool) {
+
+		root := filepath.Join(t.TempDir(), "root")
+
+		dbo := &DbObject{
+			Database: database,
+			Schema:   schema,
+			ObjType:  objtype,
+			Name:     name,
+			AclFiles: aclfiles,
+			Paths:    DbObjPath{Rootpath: root, IsCustom: custom},
+		}
+		dbo.Content.WriteString("CREATE TABLE " + name + " ();\n")
+
+		initKnownViews()
+		dbo.normalizeDbObject()
+		dbo.generateDestinationPath()
+
+		if dbo.Paths.FullPath == "" {
+			t.Fatalf("empty path generated for %+v", dbo)
+		}
+
+		if !isUnderRoot(root, dbo.Paths.FullPath) {
+			t.Fatalf("path %s generated outside of the root directory", dbo.Paths.FullPath)
+		}
+
+		if !strings.HasSuffix(dbo.Paths.FullPath, ".sql") {
+			t.Fatalf("path %s without .sql extension", dbo.Paths.FullPath)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package dbobject
+
+import (
+	"regexp"
+)
+
+var rgx_roleDbSetting *regexp.Regexp
+var rgx_tablespaceStmt *regexp.Regexp
+var rgx_parameterAcl *regexp.Regexp
+
+func init() {
+	rgx_roleDbSetting = regexp.MustCompile(`^ALTER ROLE ` + rgxRoleName + ` IN DATABASE `)
+	rgx_tablespaceStmt = regexp.MustCompile(`^(?:(?:CREATE|ALTER|COMMENT ON|SECURITY LABEL FOR \S+ ON) TABLESPACE|(?:GRANT|REVOKE) .* ON TABLESPACE) ` + rgxRoleName)
+	rgx_parameterAcl = regexp.MustCompile(`^(?:GRANT|REVOKE) .* ON PARAMETER ` + rgxRoleName)
+
+	// sections of pg_dumpall globals other than roles
+	globalsSections["Tablespaces"] = globalsSection{objtype: "TABLESPACE", split: tablespaceOfStatement}
+	globalsSections["Role privileges on configuration parameters"] = globalsSection{objtype: "PARAMETER ACL", split: parameterOfStatement}
+	// written prior to 11, settings are part of the dump of the database since then
+	globalsSections["Per-Database Role Settings"] = globalsSection{objtype: "ROLE SETTINGS", split: roleOfDbSetting, roles: true}
+	globalsSections["Drop databases"] = globalsSection{objtype: "DROP", name: "databases"}
+	globalsSections["Drop roles"] = globalsSection{objtype: "DROP", name: "roles"}
+	globalsSections["Drop tablespaces"] = globalsSection{objtype: "DROP", name: "tablespaces"}
+}
+
+// Returns the role of `ALTER ROLE role IN DATABASE db SET ...` statement
+func roleOfDbSetting(line string) (string, bool) {
+
+	if m := rgx_roleDbSetting.FindStringSubmatch(line); m != nil {
+		return unquoteIdent(m[1]), true
+	}
+
+	return "", false
+}
+
+// Returns the tablespace a statement of the tablespaces section belongs to
+func tablespaceOfStatement(line string) (string, bool) {
+
+	if m := rgx_tablespaceStmt.FindStringSubmatch(line); m != nil {
+		return unquoteIdent(m[1]), true
+	}
+
+	return "", false
+}
+
+// Returns the configuration parameter of `GRANT ... ON PARAMETER` statement
+func parameterOfStatement(line string) (string, bool) {
+
+	if m := rgx_parameterAcl.FindStringSubmatch(line); m != nil {
+		return unquoteIdent(m[1]), true
+	}
+
+	return "", false
+}
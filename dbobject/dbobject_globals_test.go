@@ -0,0 +1,71 @@
+package dbobject
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Splits globals of pg_dumpall --globals-only --clean of the testdata corpus
+func TestGlobalsSections(t *testing.T) {
+
+	for version := 12; version <= 18; version++ {
+
+		dump := readCorpusDump(t, fmt.Sprintf("pg_dumpall_globals_clean_%d.sql", version))
+		tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom"}, dump))
+
+		membership := "GRANT app_ro TO app GRANTED BY postgres;"
+		if version >= 16 {
+			membership = "GRANT app_ro TO app WITH INHERIT TRUE GRANTED BY postgres;"
+		}
+
+		want := map[string][]string{
+			"-/drop/roles.sql":             {"DROP ROLE \"Data Team\";\nDROP ROLE app;\nDROP ROLE app_ro;\nDROP ROLE postgres;\n"},
+			"-/drop/tablespaces.sql":       {"DROP TABLESPACE archive;\n"},
+			"-/role/app.sql":               {"CREATE ROLE app;", "ALTER ROLE app SET search_path", membership},
+			"-/role/app_ro.sql":            {"CREATE ROLE app_ro;", "COMMENT ON ROLE app_ro IS 'read only access';"},
+			"-/role/Data Team.sql":         {"CREATE ROLE \"Data Team\";", "TO \"Data Team\" "},
+			"-/role/postgres.sql":          {"ALTER ROLE postgres WITH SUPERUSER"},
+			"-/tablespace/archive.sql":     {"CREATE TABLESPACE archive OWNER app LOCATION '/var/lib/postgresql/archive';\n"},
+			"-/parameter acl/work_mem.sql": {"GRANT SET ON PARAMETER work_mem TO app;\n"},
+		}
+
+		// privileges on configuration parameters exist since 15
+		if version < 15 {
+			delete(want, "-/parameter acl/work_mem.sql")
+		}
+
+		if len(tree) != len(want) {
+			t.Errorf("pg %d: expected %d files, got: %v", version, len(want), tree)
+		}
+
+		for path, contents := range want {
+			for _, c := range contents {
+				if !strings.Contains(tree[path], c) {
+					t.Errorf("pg %d: file %s does not contain %q, got: %q", version, path, c, tree[path])
+				}
+			}
+		}
+
+		for path, content := range tree {
+			if strings.HasPrefix(path, "-/role/") && (strings.Contains(content, "TABLESPACE") || strings.Contains(content, "PARAMETER") || strings.Contains(content, "DROP")) {
+				t.Errorf("pg %d: role file %s contains statements of other sections: %s", version, path, content)
+			}
+		}
+	}
+}
+
+// pg_dumpall prior to 11 wrote settings of roles in databases into their own section
+func TestGlobalsRoleDbSettings(t *testing.T) {
+
+	for line, want := range map[string]string{
+		"ALTER ROLE app IN DATABASE shop SET search_path TO 'billing';":     "app",
+		"ALTER ROLE \"Data Team\" IN DATABASE shop SET work_mem TO '64MB';": "Data Team",
+		"ALTER ROLE app SET search_path TO 'billing';":                      "",
+		"ALTER DATABASE shop SET search_path TO 'billing';":                 "",
+	} {
+		if got, _ := roleOfDbSetting(line); got != want {
+			t.Errorf("%s: got %q, wants %q", line, got, want)
+		}
+	}
+}
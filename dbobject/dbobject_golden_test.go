@@ -0,0 +1,106 @@
+package dbobject
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Regenerates expected structures of the corpus, ie `go test ./dbobject -run TestGolden -update`
+var updateGolden = flag.Bool("update", false, "regenerate golden structures of the testdata corpus")
+
+// Splits every dump of testdata/dumps in both modes and compares the result with the structure stored in testdata/golden/{dump}/{mode}
+func TestGolden(t *testing.T) {
+
+	dumps, err := filepath.Glob(filepath.Join("testdata", "dumps", "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dumps) == 0 {
+		t.Fatal("no dumps found in testdata")
+	}
+
+	for _, dump := range dumps {
+
+		content, err := os.ReadFile(dump)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, mode := range []string{"origin", "custom"} {
+
+			name := strings.TrimSuffix(filepath.Base(dump), ".sql")
+
+			t.Run(name+"/"+mode, func(t *testing.T) {
+
+				golden := filepath.Join("testdata", "golden", name, mode)
+
+				// databases are filtered the same way as by default on the command line
+				args := Config{Mode: mode, ExDb: "^(template|postgres)"}
+
+				// the structure is split right into testdata, moving it from the temporary directory might cross filesystems
+				if *updateGolden {
+					if err := os.RemoveAll(golden); err != nil {
+						t.Fatal(err)
+					}
+					args.Dest = golden
+					splitTestDump(t, args, string(content))
+					return
+				}
+
+				dir := splitTestDump(t, args, string(content))
+
+				compareTestTrees(t, readTestTree(t, dir), readTestTree(t, golden))
+			})
+		}
+	}
+}
+
+// Reads the dump of the testdata corpus
+func readCorpusDump(t *testing.T, name string) string {
+
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join("testdata", "dumps", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(content)
+}
+
+// Reports differences between the created structure and the expected one
+func compareTestTrees(t *testing.T, got map[string]string, expected map[string]string) {
+
+	t.Helper()
+
+	var paths []string
+	for path := range got {
+		paths = append(paths, path)
+	}
+	for path := range expected {
+		if _, found := got[path]; !found {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+
+		content, found := got[path]
+		want, expectedFound := expected[path]
+
+		switch {
+		case !expectedFound:
+			t.Errorf("unexpected file %s", path)
+		case !found:
+			t.Errorf("missing file %s", path)
+		case content != want:
+			t.Errorf("content of %s differs:\n%s\nexpected:\n%s", path, content, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package dbobject
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Object found in the dump, as listed by the ls command
+type ListEntry struct {
+	Database string `json:"database"`
+	Schema   string `json:"schema"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Owner    string `json:"owner,omitempty"`
+	Line     int    `json:"line"`
+}
+
+// Objects found in the dump, in order of appearance
+type DumpListing struct {
+	Entries []*ListEntry `json:"objects"`
+}
+
+// Lists objects of the dump given by the configuration
+func ListDump(args *Config) (*DumpListing, error) {
+
+	listing := &DumpListing{Entries: []*ListEntry{}}
+
+	err := VisitDump(args, Visitor{Object: func(dbo *DbObject) error {
+		listing.add(dbo)
+		return nil
+	}})
+
+	return listing, err
+}
+
+func (listing *DumpListing) add(dbo *DbObject) {
+
+	// objects of the cluster (ie roles) are listed like in the structure, under `-`
+	database := dbo.ownDatabase()
+	if database == "" {
+		database = "-"
+	}
+
+	schema := dbo.Schema
+	if schema == "" {
+		schema = "-"
+	}
+
+	listing.Entries = append(listing.Entries, &ListEntry{
+		Database: database,
+		Schema:   schema,
+		Type:     dbo.ObjType,
+		Name:     dbo.Name,
+		Owner:    dbo.Owner,
+		Line:     dbo.Line,
+	})
+}
+
+// Prints the listing as a table, as a tree of databases and schemas or as a json document
+func (listing *DumpListing) Print(w io.Writer, format string) error {
+
+	switch format {
+
+	case "json":
+		data, err := json.MarshalIndent(listing, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+
+	case "tree":
+		return listing.printTree(w)
+
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(tw, "LINE\tDATABASE\tSCHEMA\tTYPE\tNAME\tOWNER")
+		for _, e := range listing.Entries {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", e.Line, e.Database, e.Schema, e.Type, e.Name, e.Owner)
+		}
+
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("invalid listing format: %s", format)
+}
+
+// Prints objects grouped by databases and schemas, both in order of their first appearance
+func (listing *DumpListing) printTree(w io.Writer) error {
+
+	var databases []string
+	schemas := make(map[string][]string)
+	objects := make(map[[2]string][]*ListEntry)
+
+	for _, e := range listing.Entries {
+
+		key := [2]string{e.Database, e.Schema}
+
+		if _, found := schemas[e.Database]; !found {
+			databases = append(databases, e.Database)
+		}
+
+		if _, found := objects[key]; !found {
+			schemas[e.Database] = append(schemas[e.Database], e.Schema)
+		}
+
+		objects[key] = append(objects[key], e)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, database := range databases {
+
+		fmt.Fprintf(tw, "%s\n", database)
+
+		for _, schema := range schemas[database] {
+
+			fmt.Fprintf(tw, "  %s\n", schema)
+
+			for _, e := range objects[[2]string{database, schema}] {
+				fmt.Fprintf(tw, "    %s\t%s\t%s\tline %d\n", e.Type, e.Name, e.Owner, e.Line)
+			}
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,164 @@
+package dbobject
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// Creates scanner of the dump given as a string
+func testScanner(dump string) *bufio.Scanner {
+
+	scanner := bufio.NewScanner(strings.NewReader(dump))
+	scanner.Split(preserveNewlines)
+
+	return scanner
+}
+
+// Lists objects of the dump given as a string
+func listTestDump(t *testing.T, args Config, dump string) *DumpListing {
+
+	t.Helper()
+
+	scanner := testScanner(dump)
+
+	if err := IsExclObjTypeOk(args.ExOT); err != nil {
+		t.Fatal(err)
+	}
+
+	listing := &DumpListing{Entries: []*ListEntry{}}
+
+	err := VisitStream(&args, scanner, Visitor{Object: func(dbo *DbObject) error {
+		listing.add(dbo)
+		return nil
+	}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return listing
+}
+
+func TestListDump(t *testing.T) {
+
+	listing := listTestDump(t, Config{}, testDumpRoles+testDumpCreate)
+
+	var got []string
+	for _, e := range listing.Entries {
+		got = append(got, strings.Join([]string{e.Database, e.Schema, e.Type, e.Name, e.Owner}, "|"))
+	}
+
+	expected := []string{
+		"-|-|ROLE|app|",
+		"-|-|ROLE|app_ro|",
+		"-|-|ROLE|Data Team|",
+		"-|-|ROLE|app|",
+		"-|-|ROLE|app|",
+		"-|-|ROLE|Data Team|",
+		"shop|-|DATABASE|shop|app",
+		"shop|-|SCHEMA|billing|app",
+		"shop|billing|TABLE|invoice|app",
+		"shop|billing|CONSTRAINT|invoice invoice_pkey|app",
+		"shop|billing|ACL|TABLE invoice|app",
+	}
+
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected listing:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	if table := listing.Entries[len(listing.Entries)-3]; table.Line != 104 {
+		t.Errorf("unexpected line of the table header: %d", table.Line)
+	}
+}
+
+func TestListDumpFilters(t *testing.T) {
+
+	listing := listTestDump(t, Config{ExOT: "^(ACL|ROLE)$"}, testDumpRoles+testDumpCreate)
+
+	for _, e := range listing.Entries {
+		if e.Type == "ACL" || e.Type == "ROLE" {
+			t.Errorf("excluded object listed: %+v", e)
+		}
+	}
+
+	listing = listTestDump(t, Config{WlDb: "^other$"}, testDumpCreate)
+
+	if len(listing.Entries) != 0 {
+		t.Errorf("objects of not whitelisted database listed: %+v", listing.Entries[0])
+	}
+}
+
+func TestListDumpFormats(t *testing.T) {
+
+	listing := listTestDump(t, Config{}, testDumpCreate)
+
+	var tree bytes.Buffer
+	if err := listing.Print(&tree, "tree"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(tree.String(), "shop\n  -\n    DATABASE") || !strings.Contains(tree.String(), "\n  billing\n    TABLE       invoice") {
+		t.Errorf("unexpected tree:\n%s", tree.String())
+	}
+
+	var js bytes.Buffer
+	if err := listing.Print(&js, "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded DumpListing
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || len(decoded.Entries) != len(listing.Entries) {
+		t.Errorf("unexpected json listing: %s", js.String())
+	}
+
+	if err := listing.Print(&js, "yaml"); err == nil {
+		t.Errorf("invalid format accepted")
+	}
+}
+
+// Objects of globals sections start on the line of their own first statement, not on the line of the section header
+func TestListGlobalsLines(t *testing.T) {
+
+	dump := readCorpusDump(t, "pg_dumpall_16.sql")
+	lines := strings.SplitAfter(dump, "\n")
+
+	for _, e := range listTestDump(t, Config{}, dump).Entries {
+
+		if e.Database != "-" {
+			continue
+		}
+
+		if line := lines[e.Line-1]; !strings.Contains(line, e.Name) {
+			t.Errorf("%s %s: line %d doesn't belong to the object: %q", e.Type, e.Name, e.Line, line)
+		}
+	}
+}
+
+// Objects are visited with the content they're stored with, ie without \restrict and \unrestrict lines
+func TestVisitContent(t *testing.T) {
+
+	for _, name := range []string{"pg_dump_18.sql", "pg_dumpall_18.sql", "pg_dumpall_globals_clean_18.sql"} {
+
+		dump := readCorpusDump(t, name)
+		var count int
+
+		err := VisitStream(&Config{WlDb: ".*"}, testScanner(dump), Visitor{Object: func(dbo *DbObject) error {
+			count++
+			if strings.Contains(dbo.Content.String(), `\restrict`) || strings.Contains(dbo.Content.String(), `\unrestrict`) {
+				t.Errorf("%s: %s %s contains restrict lines: %q", name, dbo.ObjType, dbo.Name, dbo.Content.String())
+			}
+			return nil
+		}})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if count == 0 {
+			t.Errorf("%s: no objects visited", name)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package dbobject
+
+import (
+	"fmt"
+	fu "pgdump_splitter/fileutils"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// identifier found in sql code, either plain or quoted
+var rgx_sqlIdent *regexp.Regexp
+var rgx_roleMembership *regexp.Regexp
+
+func init() {
+	rgx_sqlIdent = regexp.MustCompile(`"(?:[^"]|"")+"|[A-Za-z_\x{80}-\x{10FFFF}][\w$\x{80}-\x{10FFFF}]*`)
+	rgx_roleMembership = regexp.MustCompile(`^GRANT ` + rgxRoleName + ` TO ` + rgxRoleName)
+}
+
+// Function placing files of roles into databases by -mc. Copies, symbolic or hard links
+var mcLinkFile func(src, dest string) error
+
+func initMcLink(args *Config) error {
+
+	switch args.McLink {
+	case "", "copy":
+		mcLinkFile = fu.CopyFile
+	case "symlink":
+		mcLinkFile = fu.SymlinkFile
+	case "hardlink":
+		mcLinkFile = fu.HardlinkFile
+	default:
+		return fmt.Errorf("invalid value of -mc-link: %s", args.McLink)
+	}
+
+	return nil
+}
+
+// Tells whether the cluster directory `-` is kept after roles are relocated into databases.
+// Symbolic links point to it, so it's always kept for them.
+func keepClusterDir(args *Config) bool {
+	return args.McKeep || args.McLink == "symlink"
+}
+
+// Tracks roles referenced by objects of databases, so -mc relocates only roles a database uses
+type roleUsage struct {
+	// roles defined in the roles section of pg_dumpall
+	roles map[string]bool
+	// member -> roles it's a member of
+	memberships map[string][]string
+	// file of a role -> the role
+	files map[string]string
+	// database -> roles referenced by its objects
+	used map[string]map[string]bool
+}
+
+// Usage of roles of the current run. Nil if all roles are relocated
+var rolesUsage *roleUsage
+
+func initRolesUsage(args *Config) error {
+
+	rolesUsage = nil
+
+	switch args.McRoles {
+	case "", "all":
+		return nil
+	case "used":
+	default:
+		return fmt.Errorf("invalid value of -mc-roles: %s", args.McRoles)
+	}
+
+	rolesUsage = &roleUsage{
+		roles:       make(map[string]bool),
+		memberships: make(map[string][]string),
+		files:       make(map[string]string),
+		used:        make(map[string]map[string]bool),
+	}
+
+	return nil
+}
+
+// Records a role defined in the roles section, with roles it's a member of
+func (ru *roleUsage) recordRole(dbo *DbObject) {
+
+	if ru == nil {
+		return
+	}
+
+	ru.roles[dbo.Name] = true
+
+	for _, line := range strings.Split(dbo.Content.String(), "\n") {
+
+		if m := rgx_roleMembership.FindStringSubmatch(line); m != nil {
+			member := unquoteIdent(m[2])
+			ru.memberships[member] = append(ru.memberships[member], unquoteIdent(m[1]))
+		}
+	}
+}
+
+// Records the file a role is stored in
+func (ru *roleUsage) recordRoleFile(dbo *DbObject) {
+
+	if ru != nil && dbo.ObjType == "ROLE" {
+		ru.files[dbo.Paths.FullPath] = dbo.Name
+	}
+}
+
+// Follows renaming of the path written before, so role files below it are still recognized
+func (ru *roleUsage) movePath(from string, to string) {
+
+	if ru == nil {
+		return
+	}
+
+	for path, role := range ru.files {
+		if moved, ok := movedPath(path, from, to); ok {
+			delete(ru.files, path)
+			ru.files[moved] = role
+		}
+	}
+}
+
+// Records roles referenced by the object of a database: its owner and roles found in ownership,
+// privilege, default privilege, policy and user mapping statements
+func (ru *roleUsage) recordUsage(dbo *DbObject) {
+
+	if ru == nil || dbo.ObjType == "ROLE" {
+		return
+	}
+
+	dbname := dbo.ownDatabase()
+
+	used := ru.used[dbname]
+	if used == nil {
+		used = make(map[string]bool)
+		ru.used[dbname] = used
+	}
+
+	if owner := profiles.mapRole(dbo.Owner); ru.roles[owner] {
+		used[owner] = true
+	}
+
+	for _, line := range strings.Split(dbo.Content.String(), "\n") {
+
+		if !rgx_profileRoleStmt.MatchString(line) {
+			continue
+		}
+
+		for _, ident := range rgx_sqlIdent.FindAllString(line, -1) {
+			if name := unquoteIdent(ident); ru.roles[name] {
+				used[name] = true
+			}
+		}
+	}
+}
+
+// Returns roles used by the database, including roles they are (transitively) members of
+func (ru *roleUsage) usedRoles(dbname string) []string {
+
+	closure := make(map[string]bool)
+	var pending []string
+
+	for role := range ru.used[dbname] {
+		pending = append(pending, role)
+	}
+
+	for len(pending) > 0 {
+
+		role := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if closure[role] {
+			continue
+		}
+
+		closure[role] = true
+		pending = append(pending, ru.memberships[role]...)
+	}
+
+	roles := make([]string, 0, len(closure))
+	for role := range closure {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	return roles
+}
+
+// Returns function telling whether a file of the cluster directory is relocated into the database.
+// Files of roles not used by the database are skipped, any other files are relocated.
+func (ru *roleUsage) relocationFilter(dbname string) func(path string) bool {
+
+	used := make(map[string]bool)
+	for _, role := range ru.usedRoles(dbname) {
+		used[role] = true
+	}
+
+	return func(path string) bool {
+		role, isrole := ru.files[path]
+		return !isrole || used[role]
+	}
+}
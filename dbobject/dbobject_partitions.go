@@ -0,0 +1,215 @@
+package dbobject
+
+import (
+	"fmt"
+	"pgdump_splitter/output"
+	"regexp"
+	"strings"
+)
+
+// qualified name of a relation, ie public.orders or "Sales"."Orders 2024"
+const rgxQualifiedName = `("(?:[^"]|"")+"|[^\s."]+)\.("(?:[^"]|"")+"|[^\s."(;]+)`
+
+var rgx_partitionAttach *regexp.Regexp
+var rgx_partitionOf *regexp.Regexp
+
+func init() {
+	rgx_partitionAttach = regexp.MustCompile(`ALTER TABLE (?:ONLY )?` + rgxQualifiedName + ` ATTACH PARTITION ` + rgxQualifiedName)
+	rgx_partitionOf = regexp.MustCompile(`CREATE (?:UNLOGGED )?TABLE ` + rgxQualifiedName + ` PARTITION OF ` + rgxQualifiedName)
+}
+
+// Relation identified by schema and name
+type relName struct {
+	schema string
+	name   string
+}
+
+// Groups partitions with their parent tables in custom mode
+type partitionGrouper struct {
+	// separate, parent or companion
+	mode         string
+	rgx_collapse *regexp.Regexp
+
+	// partition -> its direct parent
+	parents map[relName]relName
+	// index -> table the index is built on
+	indexes map[relName]relName
+	// partitions collapsed into a representative partition of the same parent
+	collapsed map[relName]bool
+}
+
+// Grouper of the current run. Nil if partitions are handled as any other table
+var partitions *partitionGrouper
+
+func initPartitions(args *Config) error {
+
+	partitions = nil
+
+	mode := args.Partitions
+	if mode == "" {
+		mode = "separate"
+	}
+
+	switch mode {
+	case "separate", "parent", "companion":
+	default:
+		return fmt.Errorf("invalid value of partitions handling: %s", args.Partitions)
+	}
+
+	var rgx *regexp.Regexp
+	if args.PartitionCollapse != "" {
+
+		var err error
+		if rgx, err = regexp.Compile(args.PartitionCollapse); err != nil {
+			return fmt.Errorf("invalid regular expression for partition collapsing")
+		}
+	}
+
+	// grouping applies to custom mode only
+	if args.Mode == "origin" || (mode == "separate" && rgx == nil) {
+		return nil
+	}
+
+	partitions = &partitionGrouper{mode: mode, rgx_collapse: rgx}
+
+	return nil
+}
+
+// Removes quotes from an identifier found in sql code
+func unquoteIdent(ident string) string {
+
+	if len(ident) >= 2 && strings.HasPrefix(ident, `"`) && strings.HasSuffix(ident, `"`) {
+		return strings.ReplaceAll(ident[1:len(ident)-1], `""`, `"`)
+	}
+
+	return ident
+}
+
+// Collects partitions and indexes of normalized objects of a database.
+// Partitions to be collapsed are decided in order of appearance, the first matching partition of every parent is kept.
+func (pg *partitionGrouper) analyze(objs []*DbObject) {
+
+	if pg == nil {
+		return
+	}
+
+	pg.parents = make(map[relName]relName)
+	pg.indexes = make(map[relName]relName)
+	pg.collapsed = make(map[relName]bool)
+
+	for _, dbo := range objs {
+
+		content := dbo.Content.String()
+
+		switch {
+		case dbo.ObjType == "TABLE ATTACH":
+			if m := rgx_partitionAttach.FindStringSubmatch(content); m != nil {
+				pg.parents[relName{unquoteIdent(m[3]), unquoteIdent(m[4])}] = relName{unquoteIdent(m[1]), unquoteIdent(m[2])}
+			}
+		case dbo.ObjType == "TABLE":
+			if m := rgx_partitionOf.FindStringSubmatch(content); m != nil {
+				pg.parents[relName{unquoteIdent(m[1]), unquoteIdent(m[2])}] = relName{unquoteIdent(m[3]), unquoteIdent(m[4])}
+			}
+		case dbo.ObjType == "INDEX" || dbo.ObjType == "CONSTRAINT":
+			// unique and primary key constraints are backed by an index of the same name
+			if dbo.ObjSubtype == "TABLE" {
+				pg.indexes[relName{dbo.Schema, dbo.Name}] = relName{dbo.Schema, dbo.ObjSubName}
+			}
+		}
+	}
+
+	if pg.rgx_collapse == nil {
+		return
+	}
+
+	representatives := make(map[relName]bool)
+
+	for _, dbo := range objs {
+
+		part := relName{dbo.Schema, dbo.Name}
+
+		if dbo.ObjType != "TABLE" || !pg.rgx_collapse.MatchString(dbo.Name) {
+			continue
+		}
+
+		if _, found := pg.parents[part]; !found {
+			continue
+		}
+
+		root := pg.rootParent(part)
+
+		if representatives[root] {
+			pg.collapsed[part] = true
+		} else {
+			representatives[root] = true
+		}
+	}
+}
+
+// Follows the chain of parents of a (sub)partition up to the partitioned table
+func (pg *partitionGrouper) rootParent(rel relName) relName {
+
+	for i := 0; i < len(pg.parents); i++ {
+
+		parent, found := pg.parents[rel]
+		if !found {
+			break
+		}
+		rel = parent
+	}
+
+	return rel
+}
+
+// Returns the table the normalized object belongs to
+func (pg *partitionGrouper) relationOf(dbo *DbObject) (relName, bool) {
+
+	switch {
+	case dbo.ObjType == "TABLE" || dbo.ObjType == "TABLE ATTACH":
+		return relName{dbo.Schema, dbo.Name}, true
+	case dbo.ObjType == "INDEX ATTACH":
+		rel, found := pg.indexes[relName{dbo.Schema, dbo.Name}]
+		return rel, found
+	case dbo.ObjSubtype == "TABLE":
+		return relName{dbo.Schema, dbo.ObjSubName}, true
+	}
+
+	return relName{}, false
+}
+
+// Moves objects of a partition to the parent table (or its companion file).
+// Returns false if the object belongs to a collapsed partition and is not supposed to be stored.
+func (pg *partitionGrouper) regroup(dbo *DbObject) bool {
+
+	if pg == nil {
+		return true
+	}
+
+	rel, found := pg.relationOf(dbo)
+	if !found {
+		return true
+	}
+
+	if pg.collapsed[rel] {
+		return false
+	}
+
+	if _, found := pg.parents[rel]; !found || pg.mode == "separate" {
+		return true
+	}
+
+	root := pg.rootParent(rel)
+
+	dbo.Schema = root.schema
+	dbo.ObjSubtype = "TABLE"
+	dbo.ObjSubName = root.name
+
+	if pg.mode == "companion" {
+		dbo.Paths.Companion = "partitions"
+	}
+
+	output.Log.Debug("regroup partition", "line", dbo.Line, "type", dbo.ObjType, "name", dbo.Name, "partition", rel.schema+"."+rel.name,
+		"parent", root.schema+"."+root.name)
+
+	return true
+}
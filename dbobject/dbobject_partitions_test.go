@@ -0,0 +1,245 @@
+package dbobject
+
+import (
+	"strings"
+	"testing"
+)
+
+// Part of the plain dump created by pg_dump 16 from a table partitioned by month
+const testDumpPartitions = `--
+-- Name: measurement; Type: TABLE; Schema: public; Owner: app
+--
+
+CREATE TABLE public.measurement (
+    city_id integer NOT NULL,
+    logdate date NOT NULL
+)
+PARTITION BY RANGE (logdate);
+
+
+ALTER TABLE public.measurement OWNER TO app;
+
+--
+-- Name: measurement_y2024m01; Type: TABLE; Schema: public; Owner: app
+--
+
+CREATE TABLE public.measurement_y2024m01 (
+    city_id integer NOT NULL,
+    logdate date NOT NULL
+);
+
+
+ALTER TABLE public.measurement_y2024m01 OWNER TO app;
+
+--
+-- Name: measurement_y2024m02; Type: TABLE; Schema: public; Owner: app
+--
+
+CREATE TABLE public.measurement_y2024m02 (
+    city_id integer NOT NULL,
+    logdate date NOT NULL
+);
+
+
+ALTER TABLE public.measurement_y2024m02 OWNER TO app;
+
+--
+-- Name: measurement_y2024m01; Type: TABLE ATTACH; Schema: public; Owner: app
+--
+
+ALTER TABLE ONLY public.measurement ATTACH PARTITION public.measurement_y2024m01 FOR VALUES FROM ('2024-01-01') TO ('2024-02-01');
+
+
+--
+-- Name: measurement_y2024m02; Type: TABLE ATTACH; Schema: public; Owner: app
+--
+
+ALTER TABLE ONLY public.measurement ATTACH PARTITION public.measurement_y2024m02 FOR VALUES FROM ('2024-02-01') TO ('2024-03-01');
+
+
+--
+-- Name: measurement measurement_pkey; Type: CONSTRAINT; Schema: public; Owner: app
+--
+
+ALTER TABLE ONLY public.measurement
+    ADD CONSTRAINT measurement_pkey PRIMARY KEY (city_id, logdate);
+
+
+--
+-- Name: measurement_y2024m01 measurement_y2024m01_pkey; Type: CONSTRAINT; Schema: public; Owner: app
+--
+
+ALTER TABLE ONLY public.measurement_y2024m01
+    ADD CONSTRAINT measurement_y2024m01_pkey PRIMARY KEY (city_id, logdate);
+
+
+--
+-- Name: measurement_y2024m02 measurement_y2024m02_pkey; Type: CONSTRAINT; Schema: public; Owner: app
+--
+
+ALTER TABLE ONLY public.measurement_y2024m02
+    ADD CONSTRAINT measurement_y2024m02_pkey PRIMARY KEY (city_id, logdate);
+
+
+--
+-- Name: measurement_y2024m01_pkey; Type: INDEX ATTACH; Schema: public; Owner: -
+--
+
+ALTER INDEX public.measurement_pkey ATTACH PARTITION public.measurement_y2024m01_pkey;
+
+
+--
+-- Name: measurement_y2024m02_pkey; Type: INDEX ATTACH; Schema: public; Owner: -
+--
+
+ALTER INDEX public.measurement_pkey ATTACH PARTITION public.measurement_y2024m02_pkey;
+
+
+--
+-- PostgreSQL database dump complete
+--
+
+`
+
+func TestPartitionsSeparate(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom"}, testDumpPartitions))
+
+	for _, want := range []string{
+		"public/table/measurement.sql",
+		"public/table/measurement_y2024m01.sql",
+		"public/table/measurement_y2024m02.sql",
+		"public/table attach/measurement_y2024m01.sql",
+		"public/index attach/measurement_y2024m01_pkey.sql",
+	} {
+		if _, found := tree[want]; !found {
+			t.Errorf("expected file %s, got: %v", want, tree)
+		}
+	}
+}
+
+func TestPartitionsParent(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", Partitions: "parent"}, testDumpPartitions))
+
+	if len(tree) != 1 {
+		t.Errorf("expected only the parent table file, got: %v", tree)
+	}
+
+	parent := tree["public/table/measurement.sql"]
+
+	for _, want := range []string{
+		"PARTITION BY RANGE (logdate);",
+		"CREATE TABLE public.measurement_y2024m02 (",
+		"ATTACH PARTITION public.measurement_y2024m01 FOR VALUES",
+		"ADD CONSTRAINT measurement_y2024m02_pkey PRIMARY KEY",
+		"ALTER INDEX public.measurement_pkey ATTACH PARTITION public.measurement_y2024m02_pkey;",
+	} {
+		if !strings.Contains(parent, want) {
+			t.Errorf("parent table file does not contain %s, got: %s", want, parent)
+		}
+	}
+}
+
+// Indexes of partitioned tables are created `ON ONLY` the parent
+func TestPartitionsParentIndex(t *testing.T) {
+
+	dump := testDumpPartitions + `--
+-- Name: measurement_logdate_idx; Type: INDEX; Schema: public; Owner: app
+--
+
+CREATE INDEX measurement_logdate_idx ON ONLY public.measurement USING btree (logdate);
+
+
+--
+-- Name: measurement_y2024m01_logdate_idx; Type: INDEX; Schema: public; Owner: app
+--
+
+CREATE INDEX measurement_y2024m01_logdate_idx ON public.measurement_y2024m01 USING btree (logdate);
+
+
+`
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom"}, dump))
+
+	if parent := tree["public/table/measurement.sql"]; !strings.Contains(parent, "CREATE INDEX measurement_logdate_idx ON ONLY public.measurement ") {
+		t.Errorf("expected the index in the file of the partitioned table, got: %s", parent)
+	}
+
+	if partition := tree["public/table/measurement_y2024m01.sql"]; !strings.Contains(partition, "CREATE INDEX measurement_y2024m01_logdate_idx ") {
+		t.Errorf("expected the index in the file of the partition, got: %s", partition)
+	}
+
+	for path := range tree {
+		if strings.HasPrefix(path, "public/index/") {
+			t.Errorf("unexpected file %s", path)
+		}
+	}
+}
+
+func TestPartitionsCompanion(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", Partitions: "companion"}, testDumpPartitions))
+
+	if len(tree) != 2 {
+		t.Errorf("expected parent table and companion files, got: %v", tree)
+	}
+
+	if parent := tree["public/table/measurement.sql"]; strings.Contains(parent, "measurement_y2024m01") {
+		t.Errorf("parent table file should not contain partitions, got: %s", parent)
+	}
+
+	if companion := tree["public/table/measurement.partitions.sql"]; !strings.Contains(companion, "CREATE TABLE public.measurement_y2024m01 (") {
+		t.Errorf("companion file should contain partitions, got: %s", companion)
+	}
+}
+
+func TestPartitionsCollapse(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", PartitionCollapse: `_y[0-9]{4}m[0-9]{2}$`}, testDumpPartitions))
+
+	if _, found := tree["public/table/measurement_y2024m01.sql"]; !found {
+		t.Errorf("the first partition should be kept as a representative, got: %v", tree)
+	}
+
+	for path := range tree {
+		if strings.Contains(path, "measurement_y2024m02") {
+			t.Errorf("collapsed partition should not be stored, got: %s", path)
+		}
+	}
+
+	if excluded := stats.Excluded["partition-collapse"]; excluded != 4 {
+		t.Errorf("expected 4 objects of the collapsed partition excluded, got: %d", excluded)
+	}
+}
+
+// pg_dump 10 creates partitions by PARTITION OF clause
+func TestPartitionsPartitionOf(t *testing.T) {
+
+	dump := `--
+-- Name: sales; Type: TABLE; Schema: public; Owner: app
+--
+
+CREATE TABLE public.sales (
+    id integer NOT NULL,
+    region text NOT NULL
+)
+PARTITION BY LIST (region);
+
+
+--
+-- Name: sales_eu; Type: TABLE; Schema: public; Owner: app
+--
+
+CREATE TABLE public.sales_eu PARTITION OF public.sales
+FOR VALUES IN ('eu');
+
+
+`
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", Partitions: "parent"}, dump))
+
+	if parent := tree["public/table/sales.sql"]; len(tree) != 1 || !strings.Contains(parent, "PARTITION OF public.sales") {
+		t.Errorf("partition should be stored in the parent table file, got: %v", tree)
+	}
+}
@@ -0,0 +1,360 @@
+package dbobject
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"pgdump_splitter/output"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Maximum length (in bytes) of a single path component accepted by common filesystems
+const maxPathComponentLen = 255
+
+// Names which cannot be used as file names on Windows, regardless of the extension
+var reservedFileNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// Checks whether the byte has to be escaped in order to get portable file name
+func isUnsafePathByte(b byte) bool {
+
+	if b < 0x20 || b == 0x7f {
+		return true
+	}
+
+	switch b {
+	case '%', '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+		return true
+	}
+
+	return false
+}
+
+// Makes a db object name safe to be used as a single path component.
+// Unsafe characters are percent-encoded (the same way as in URLs), so the original name might be restored by UnescapePathComponent.
+// Additionally it escapes:
+//   - `.` and `..` which otherwise would navigate through the directory tree
+//   - names reserved by Windows (CON, aux, LPT1.txt, ...)
+//   - trailing dots and spaces which are silently stripped by Windows
+//   - bytes of invalid utf8 sequences
+func EscapePathComponent(name string) string {
+
+	if name == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(name); {
+
+		// bytes of invalid utf8 sequences are escaped one by one, file names of most filesystems are expected to be utf8
+		r, size := utf8.DecodeRuneInString(name[i:])
+
+		if isUnsafePathByte(name[i]) || (r == utf8.RuneError && size == 1) {
+			fmt.Fprintf(&sb, "%%%02X", name[i])
+		} else {
+			sb.WriteString(name[i : i+size])
+		}
+
+		i += size
+	}
+
+	escaped := sb.String()
+
+	if strings.Trim(escaped, ".") == "" {
+		return strings.ReplaceAll(escaped, ".", "%2E")
+	}
+
+	if base, _, _ := strings.Cut(escaped, "."); reservedFileNames[strings.ToUpper(base)] {
+		escaped = fmt.Sprintf("%%%02X", escaped[0]) + escaped[1:]
+	}
+
+	if last := escaped[len(escaped)-1]; last == '.' || last == ' ' {
+		escaped = escaped[:len(escaped)-1] + fmt.Sprintf("%%%02X", last)
+	}
+
+	return escaped
+}
+
+// Restores original name from the path component created by EscapePathComponent
+func UnescapePathComponent(name string) (string, error) {
+
+	var sb strings.Builder
+
+	for i := 0; i < len(name); i++ {
+
+		if name[i] != '%' {
+			sb.WriteByte(name[i])
+			continue
+		}
+
+		if i+2 >= len(name) {
+			return "", fmt.Errorf("invalid escape sequence at the end of: %s", name)
+		}
+
+		b, err := strconv.ParseUint(name[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape sequence in: %s", name)
+		}
+
+		sb.WriteByte(byte(b))
+		i += 2
+	}
+
+	return sb.String(), nil
+}
+
+// Shortens path component exceeding the filesystem limit.
+// The component is cut (respecting utf8 boundaries) and suffixed with a hash calculated from its full value, keeping the extension.
+func shortenPathComponent(comp string, ext string) string {
+
+	hash := "-" + funcArgsToHash(comp)[0:6]
+	base := strings.TrimSuffix(comp, ext)
+	limit := maxPathComponentLen - len(hash) - len(ext)
+
+	for len(base) > limit || !utf8.ValidString(base) {
+		base = base[:len(base)-1]
+	}
+
+	return base + hash + ext
+}
+
+// Returns extension of the file generated for db object, incl the `.acl` part if present
+func pathComponentExt(comp string) string {
+
+	if strings.HasSuffix(comp, ".acl.sql") {
+		return ".acl.sql"
+	}
+
+	return filepath.Ext(comp)
+}
+
+// Resolves paths generated for db objects against each other.
+// It makes sure that:
+//   - every path component fits into the filesystem limits
+//   - there are no paths which differ in the letter case only, since they would collide on case-insensitive filesystems (`Users` vs `users`)
+//
+// Depending on configuration, found issues are either reported as errors or fixed by renaming.
+// Of the colliding names, the lexicographically greater one gets the `~hash` suffix, so the result doesn't depend on the order of the dump.
+// Paths found in the destination directory (unless it's cleaned) keep their names, only the new ones are renamed.
+type pathResolver struct {
+	failOnConflict bool
+	resolved       map[string]string
+	seen           map[string]string
+	// lower cased paths, which existed in the destination directory before the run
+	existed map[string]bool
+	// paths renamed after they had been written -> their new names
+	renamed map[string]string
+}
+
+var pathres *pathResolver
+
+// Initializes path resolver.
+// Allowed modes are `rename` and `error`
+func initPathResolver(args *Config) error {
+
+	switch args.PathConflicts {
+	case "", "rename":
+		pathres = &pathResolver{}
+	case "error":
+		pathres = &pathResolver{failOnConflict: true}
+	default:
+		return fmt.Errorf("invalid value of path conflicts mode: %s", args.PathConflicts)
+	}
+
+	pathres.resolved = make(map[string]string)
+	pathres.seen = make(map[string]string)
+	pathres.existed = make(map[string]bool)
+	pathres.renamed = make(map[string]string)
+
+	if args.Cln || args.Dest == "" {
+		return nil
+	}
+
+	return pathres.seedDir(args.Dest)
+}
+
+// Records paths existing in the directory, so new paths are resolved against them
+func (pr *pathResolver) seedDir(dir string) error {
+
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if path != dir {
+			pr.seen[strings.ToLower(path)] = path
+			pr.existed[strings.ToLower(path)] = true
+		}
+
+		return nil
+	})
+}
+
+// Returns path which is safe to be written to, for the given path generated for the db object.
+// The same input always results in the same output within single run, unless the path gets renamed
+// by a colliding path resolved later.
+func (pr *pathResolver) resolve(rootpath string, fullpath string) (string, error) {
+
+	if res, ok := pr.resolved[fullpath]; ok {
+		return res, nil
+	}
+
+	relpath, err := filepath.Rel(rootpath, fullpath)
+	if err != nil || relpath == ".." || strings.HasPrefix(relpath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path is outside of destination directory: %s", fullpath)
+	}
+
+	comps := strings.Split(relpath, string(filepath.Separator))
+	current := rootpath
+
+	for i, comp := range comps {
+
+		var ext string
+		if i == len(comps)-1 {
+			ext = pathComponentExt(comp)
+		}
+
+		if len(comp) > maxPathComponentLen {
+			if pr.failOnConflict {
+				return "", fmt.Errorf("path component exceeds %d bytes: %s", maxPathComponentLen, comp)
+			}
+			comp = shortenPathComponent(comp, ext)
+		}
+
+		candidate := filepath.Join(current, comp)
+		existing, found := pr.seen[strings.ToLower(candidate)]
+
+		if found && existing != candidate {
+
+			if pr.failOnConflict {
+				return "", fmt.Errorf("path %s collides with %s on case-insensitive filesystems", candidate, existing)
+			}
+
+			if pr.existed[strings.ToLower(existing)] || comp > filepath.Base(existing) {
+				candidate = pr.disambiguate(current, comp, ext)
+			} else {
+				// the path written before gives way to the lesser name
+				var existingExt string
+				if i == len(comps)-1 {
+					existingExt = pathComponentExt(filepath.Base(existing))
+				}
+				if err := pr.move(existing, pr.disambiguate(current, filepath.Base(existing), existingExt)); err != nil {
+					return "", err
+				}
+			}
+		}
+
+		pr.seen[strings.ToLower(candidate)] = candidate
+		current = candidate
+	}
+
+	pr.resolved[fullpath] = current
+
+	return current, nil
+}
+
+// Returns path of the component in the directory, with the name suffixed by its hash until it collides with no other path
+func (pr *pathResolver) disambiguate(dir string, comp string, ext string) string {
+
+	candidate := filepath.Join(dir, comp)
+
+	for {
+		if _, found := pr.seen[strings.ToLower(candidate)]; !found {
+			return candidate
+		}
+
+		comp = strings.TrimSuffix(comp, ext) + "~" + funcArgsToHash(comp)[0:6] + ext
+		if len(comp) > maxPathComponentLen {
+			comp = shortenPathComponent(comp, ext)
+		}
+		candidate = filepath.Join(dir, comp)
+	}
+}
+
+// Renames the path resolved before (together with everything below it), on the disk or in the dry run plan,
+// and in the state of the run keyed by paths
+func (pr *pathResolver) move(from string, to string) error {
+
+	output.Log.Debug("rename colliding path", "from", from, "to", to)
+
+	if dryrun != nil {
+		dryrun.planMove(from, to)
+	} else if _, err := os.Lstat(from); err == nil {
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+	}
+
+	for key, res := range pr.resolved {
+		if moved, ok := movedPath(res, from, to); ok {
+			pr.resolved[key] = moved
+		}
+	}
+
+	for key, res := range pr.seen {
+		if moved, ok := movedPath(res, from, to); ok {
+			delete(pr.seen, key)
+			pr.seen[strings.ToLower(moved)] = moved
+		}
+	}
+
+	for old, res := range pr.renamed {
+		if moved, ok := movedPath(res, from, to); ok {
+			pr.renamed[old] = moved
+		}
+	}
+	pr.renamed[from] = to
+
+	stats.movePath(from, to)
+	rolesUsage.movePath(from, to)
+
+	return nil
+}
+
+// Returns the current path of a path resolved before, following later renames
+func (pr *pathResolver) current(path string) string {
+
+	for from, to := range pr.renamed {
+		if moved, ok := movedPath(path, from, to); ok {
+			return pr.current(moved)
+		}
+	}
+
+	return path
+}
+
+// Returns path with the prefix `from` replaced by `to`, if the path is or lies below `from`
+func movedPath(path string, from string, to string) (string, bool) {
+
+	if path == from {
+		return to, true
+	}
+
+	if strings.HasPrefix(path, from+string(filepath.Separator)) {
+		return to + strings.TrimPrefix(path, from), true
+	}
+
+	return path, false
+}
+
+// Returns path, where the file which isn't a db object (ie. file of unclassified content) is written.
+// It's resolved the same way as paths of db objects, so it ends up in the same (possibly renamed) directories.
+func resolveOutputPath(rootpath string, fullpath string) (string, error) {
+
+	if pathres == nil {
+		return fullpath, nil
+	}
+
+	return pathres.resolve(rootpath, fullpath)
+}
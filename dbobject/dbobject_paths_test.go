@@ -0,0 +1,254 @@
+package dbobject
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEscapePathComponent(t *testing.T) {
+
+	cases := map[string]string{
+		"users":       "users",
+		"-":           "-",
+		"a/b":         "a%2Fb",
+		"..":          "%2E%2E",
+		".":           "%2E",
+		"100%":        "100%25",
+		"CON":         "%43ON",
+		"aux":         "%61ux",
+		"lpt1.backup": "%6Cpt1.backup",
+		"console":     "console",
+		"nul\x00x":    "nul%00x",
+		"tab\tname":   "tab%09name",
+		"trailing.":   "trailing%2E",
+		"trailing ":   "trailing%20",
+		"with space":  "with space",
+		"žluťoučký":   "žluťoučký",
+		"bil\x80ing":  "bil%80ing",
+		"lu\xc5":      "lu%C5",
+	}
+
+	for src, want := range cases {
+
+		got := EscapePathComponent(src)
+		if want != got {
+			t.Errorf("got %s, wants %s", got, want)
+		}
+
+		back, err := UnescapePathComponent(got)
+		if err != nil {
+			t.Errorf("unescape of %s failed: %s", got, err.Error())
+		}
+
+		if back != src {
+			t.Errorf("got %s, wants %s", back, src)
+		}
+	}
+}
+
+func TestDestinationPathStaysUnderRoot(t *testing.T) {
+
+	dbo := DbObject{
+		Name:     "../../etc/passwd",
+		ObjType:  "TABLE",
+		Schema:   "..",
+		Database: "..",
+		Paths:    DbObjPath{Rootpath: "/root/", IsCustom: true},
+	}
+
+	dbo.normalizeDbObject()
+	dbo.generateDestinationPath()
+
+	want := "/root/%2E%2E/%2E%2E/table/..%2F..%2Fetc%2Fpasswd.sql"
+
+	if want != dbo.Paths.FullPath {
+		t.Errorf("got %s, wants %s", dbo.Paths.FullPath, want)
+	}
+}
+
+func TestObjTypePathStaysUnderRoot(t *testing.T) {
+
+	for _, tc := range []struct {
+		objtype string
+		custom  bool
+		want    string
+	}{
+		{"../../etc", true, "/root/shop/public/..%2F..%2Fetc/orders.sql"},
+		{"../../etc", false, "/root/shop/public/..%2F..%2Fetc/orders.sql"},
+		{"..", true, "/root/shop/public/%2E%2E/orders.sql"},
+		{"A/B", false, "/root/shop/public/A%2FB/orders.sql"},
+	} {
+
+		dbo := DbObject{
+			Name:     "orders",
+			ObjType:  tc.objtype,
+			Schema:   "public",
+			Database: "shop",
+			Paths:    DbObjPath{Rootpath: "/root/", IsCustom: tc.custom},
+		}
+
+		dbo.normalizeDbObject()
+		dbo.generateDestinationPath()
+
+		if tc.want != dbo.Paths.FullPath {
+			t.Errorf("got %s, wants %s", dbo.Paths.FullPath, tc.want)
+		}
+	}
+}
+
+func TestPathResolverCaseCollision(t *testing.T) {
+
+	if err := initPathResolver(&Config{PathConflicts: "rename"}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := pathres.resolve("/root", "/root/db/public/table/users.sql")
+	second, _ := pathres.resolve("/root", "/root/db/public/table/Users.sql")
+	again, _ := pathres.resolve("/root", "/root/db/public/table/users.sql")
+	schema, _ := pathres.resolve("/root", "/root/db/Public/table/orders.sql")
+	moved, _ := pathres.resolve("/root", "/root/db/public/table/users.sql")
+
+	if first != "/root/db/public/table/users.sql" {
+		t.Errorf("got %s, wants /root/db/public/table/users.sql", first)
+	}
+
+	// the lexicographically greater name gets renamed, even if it came first
+	if second != "/root/db/public/table/Users.sql" {
+		t.Errorf("got %s, wants /root/db/public/table/Users.sql", second)
+	}
+
+	if !strings.HasPrefix(again, "/root/db/public/table/users~") || !strings.HasSuffix(again, ".sql") {
+		t.Errorf("got %s, expected disambiguated file name", again)
+	}
+
+	if schema != "/root/db/Public/table/orders.sql" || !strings.HasPrefix(moved, "/root/db/public~") {
+		t.Errorf("got %s and %s, expected disambiguated directory name", schema, moved)
+	}
+
+	if pathres.current(first) != moved {
+		t.Errorf("got %s, wants %s", pathres.current(first), moved)
+	}
+
+	if err := initPathResolver(&Config{PathConflicts: "error"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pathres.resolve("/root", "/root/db/public/table/users.acl.sql")
+	if _, err := pathres.resolve("/root", "/root/db/public/table/USERS.acl.sql"); err == nil {
+		t.Errorf("expected collision error")
+	}
+}
+
+func TestPathResolverLongName(t *testing.T) {
+
+	if err := initPathResolver(&Config{PathConflicts: "rename"}); err != nil {
+		t.Fatal(err)
+	}
+
+	long := strings.Repeat("ž", 200)
+	got, err := pathres.resolve("/root", "/root/db/public/table/"+long+".acl.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := got[strings.LastIndex(got, "/")+1:]
+	if len(filename) > maxPathComponentLen || !strings.HasSuffix(filename, ".acl.sql") {
+		t.Errorf("got %s, expected shortened file name", filename)
+	}
+
+	if err := initPathResolver(&Config{PathConflicts: "error"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pathres.resolve("/root", "/root/db/public/table/"+long+".sql"); err == nil {
+		t.Errorf("expected error for too long path component")
+	}
+}
+
+// Case colliding objects result in the same files, regardless of their order in the dump
+func TestPathResolverOrder(t *testing.T) {
+
+	const lower = `--
+-- Name: users; Type: TABLE; Schema: public; Owner: app
+--
+
+CREATE TABLE public.users (id integer);
+
+`
+	const upper = `--
+-- Name: Users; Type: TABLE; Schema: public; Owner: app
+--
+
+CREATE TABLE public."Users" (id integer);
+
+`
+
+	for _, dryRun := range []bool{false, true} {
+
+		first := readTestTree(t, splitTestDump(t, Config{Mode: "origin", DryRun: dryRun}, lower+upper))
+		planned := dryrun
+		second := readTestTree(t, splitTestDump(t, Config{Mode: "origin", DryRun: dryRun}, upper+lower))
+
+		if dryRun {
+			var paths []string
+			for _, pf := range planned.Files {
+				paths = append(paths, pf.Path[strings.Index(pf.Path, "/public/"):])
+			}
+			if len(paths) != 2 || paths[0] != "/public/TABLE/users~"+funcArgsToHash("users.sql")[0:6]+".sql" || paths[1] != "/public/TABLE/Users.sql" {
+				t.Errorf("unexpected planned files: %v", paths)
+			}
+			continue
+		}
+
+		if len(first) != 2 || len(second) != 2 {
+			t.Fatalf("unexpected trees: %v, %v", first, second)
+		}
+
+		for path, content := range first {
+			if second[path] != content {
+				t.Errorf("%s differs: %q vs %q", path, content, second[path])
+			}
+		}
+
+		if !strings.Contains(first["public/TABLE/Users.sql"], `"Users"`) {
+			t.Errorf("unexpected files: %v", first)
+		}
+	}
+}
+
+// Files found in the destination directory keep their names, new colliding paths get renamed
+func TestPathResolverExistingFiles(t *testing.T) {
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "db", "public", "table"), 0770); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "db", "public", "table", "users.sql"), []byte("old"), 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := initPathResolver(&Config{Dest: dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := pathres.resolve(dir, filepath.Join(dir, "db", "public", "table", "Users.sql"))
+	if !strings.HasPrefix(got, filepath.Join(dir, "db", "public", "table", "Users~")) {
+		t.Errorf("got %s, expected disambiguated file name", got)
+	}
+
+	same, _ := pathres.resolve(dir, filepath.Join(dir, "db", "public", "table", "users.sql"))
+	if same != filepath.Join(dir, "db", "public", "table", "users.sql") {
+		t.Errorf("got %s, expected existing file", same)
+	}
+
+	// cleaned destination doesn't constrain new paths
+	if err := initPathResolver(&Config{Dest: dir, Cln: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := pathres.resolve(dir, filepath.Join(dir, "db", "public", "table", "Users.sql")); got != filepath.Join(dir, "db", "public", "table", "Users.sql") {
+		t.Errorf("got %s, expected unchanged path", got)
+	}
+}
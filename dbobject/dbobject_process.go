@@ -25,8 +25,8 @@ func init() {
 	rgx_conn = regexp.MustCompile(`^\\connect( -reuse-previous=on)? (("dbname='(.*?)'")|(.*))`)
 	rgx_users = regexp.MustCompile(`^-- (User Configurations|Databases)[\s]*$`)
 	rgx_dbdump = regexp.MustCompile(`^-- PostgreSQL database dump[\s]*(complete)?[\s]*$`)
-	rgx_roles = regexp.MustCompile(`(^-- (?P<Type1>Roles|Role memberships)[\s]*$)|(^-- (?P<Type2>User Config) \".*\"[\s]*$)`)
-	rgx_common = regexp.MustCompile(`^-- (Data for )?Name: "?(?P<Name>.*)"?; Type: (?P<Type>.*); Schema: "?(?P<Schema>.*)"?;`)
+	rgx_roles = regexp.MustCompile(`(^-- (?P<Type1>Roles|Role memberships|Tablespaces|Role privileges on configuration parameters|Per-Database Role Settings|Drop roles|Drop tablespaces|Drop databases)( \(except postgres and template1\))?[\s]*$)|(^-- (?P<Type2>User Config) \"(?P<Role>.*)\"[\s]*$)`)
+	rgx_common = regexp.MustCompile(`^-- (Data for )?Name: "?(?P<Name>.*)"?; Type: (?P<Type>.*); Schema: "?(?P<Schema>.*)"?;( Owner: (?P<Owner>[^\r\n]*))?`)
 
 }
 
@@ -46,7 +46,7 @@ func StartProcessing(args *Config) error {
 
 	// wipe destination directory if requested.
 	// leaving data might result in appending DDLs to existing files
-	if args.Cln {
+	if args.Cln && !args.DryRun {
 		if err = fu.WipeDir(args.Dest); err != nil {
 			return err
 		}
@@ -62,9 +62,29 @@ func StartProcessing(args *Config) error {
 		return err
 	}
 
-	// Remove cluster subdirectory (if exists), if Move Cluster Data has been selected
-	if args.MvRl {
-		if err = os.RemoveAll(filepath.Join(args.Dest, "-")); err != nil {
+	// Remove cluster subdirectory (if exists), if Move Cluster Data has been selected and the directory isn't kept
+	if args.MvRl && !keepClusterDir(args) {
+		if dryrun != nil {
+			dryrun.planRemoveDir(filepath.Join(args.Dest, "-"))
+		} else if err = os.RemoveAll(filepath.Join(args.Dest, "-")); err != nil {
+			return err
+		}
+	}
+
+	if dryrun != nil {
+		if err = dryrun.Print(os.Stdout, args.DryRunFormat); err != nil {
+			return err
+		}
+	}
+
+	if args.Stats {
+		if err = stats.Print(os.Stdout, "text"); err != nil {
+			return err
+		}
+	}
+
+	if args.StatsJson != "" {
+		if err = stats.WriteJson(args.StatsJson); err != nil {
 			return err
 		}
 	}
@@ -77,6 +97,8 @@ func StartProcessing(args *Config) error {
 // If so, assign to the rgx_ExcludeObjType
 func IsExclObjTypeOk(rgx string) error {
 
+	rgx_ExcludeObjType = nil
+
 	if rgx == "" {
 		return nil
 	}
@@ -91,6 +113,30 @@ func IsExclObjTypeOk(rgx string) error {
 
 }
 
+// Compiles regular expressions blacklisting and whitelisting databases
+func initDbFilters(args *Config) error {
+
+	var err error
+
+	rgx_ExclDb, rgx_WhiteListDb = nil, nil
+
+	if args.ExDb != "" {
+		rgx_ExclDb, err = regexp.Compile(args.ExDb)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression for databases exclusion")
+		}
+	}
+
+	if args.WlDb != "" {
+		rgx_WhiteListDb, err = regexp.Compile(args.WlDb)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression for databases whitelisting")
+		}
+	}
+
+	return nil
+}
+
 // Decide whethere currently scanned database is selected/blacklisted
 func enableCurrentDb(dbname string) bool {
 
@@ -120,22 +166,40 @@ func allowObject(dbo *DbObject) bool {
 	// Exclude objects by type given by regular expression passed with prog arguments
 	if rgx_ExcludeObjType != nil {
 		if rgx_ExcludeObjType.MatchString(dbo.ObjType) || rgx_ExcludeObjType.MatchString(dbo.ObjSubtype) {
+			excludeObject(dbo, "exclude-objects")
 			return false
 		}
 	}
 
-	if dbo.ObjType == "DATABASE" {
-		return enableCurrentDb(dbo.Name)
+	if skipMatviewData && dbo.ObjType == "MATERIALIZED VIEW DATA" {
+		excludeObject(dbo, "skip-matview-data")
+		return false
 	}
 
-	if dbo.Database != "" && dbo.Database != "-" {
-		return enableCurrentDb(dbo.Database)
+	if dbname := dbo.ownDatabase(); dbname != "" && dbname != "-" && !enableCurrentDb(dbname) {
+		excludeObject(dbo, "database")
+		return false
+	}
+
+	if dbo.ObjType != "" {
+		output.Log.Debug("allow object", "line", dbo.Line, "type", dbo.ObjType, "name", dbo.Name, "database", dbo.Database)
 	}
 
 	return true
 
 }
 
+// Records exclusion of the object by the filter of given name
+func excludeObject(dbo *DbObject, filter string) {
+
+	if dbo.ObjType == "" {
+		return
+	}
+
+	output.Log.Debug("exclude object", "line", dbo.Line, "type", dbo.ObjType, "name", dbo.Name, "database", dbo.Database, "filter", filter)
+	stats.addExcluded(dbo, filter)
+}
+
 // Most outer processing function.
 // It initializes a stream either from a file or pgdump, and processes it line by line.
 func ProcessStream(args *Config, scanner *bufio.Scanner) error {
@@ -147,19 +211,11 @@ func ProcessStream(args *Config, scanner *bufio.Scanner) error {
 	var curObj DbObject
 	var err error
 	var processdb bool = true
+	// set between the beginning and the end of a database dump
+	var dbopen bool
 
-	if args.ExDb != "" {
-		rgx_ExclDb, err = regexp.Compile(args.ExDb)
-		if err != nil {
-			return fmt.Errorf("invalid regular expression for databases exclusion")
-		}
-	}
-
-	if args.WlDb != "" {
-		rgx_WhiteListDb, err = regexp.Compile(args.WlDb)
-		if err != nil {
-			return fmt.Errorf("invalid regular expression for databases whitelisting")
-		}
+	if err = initDbFilters(args); err != nil {
+		return err
 	}
 
 	rgx := `^\\(un)?restrict `
@@ -172,13 +228,66 @@ func ProcessStream(args *Config, scanner *bufio.Scanner) error {
 		return fmt.Errorf("invalid Restrict argument; breaks regular expression compilation")
 	}
 
+	if err = initPathResolver(args); err != nil {
+		return err
+	}
+
+	if err = initProfiles(args.Normalize, args.RoleMap); err != nil {
+		return err
+	}
+
+	if err = initDumpInfo(args); err != nil {
+		return err
+	}
+
+	if err = initDryRun(args); err != nil {
+		return err
+	}
+
+	initStats()
+	initKnownViews()
+	deferred = nil
+
+	if err = initPartitions(args); err != nil {
+		return err
+	}
+
+	if err = initTenants(args); err != nil {
+		return err
+	}
+
+	if err = initRolesUsage(args); err != nil {
+		return err
+	}
+
+	if err = initMcLink(args); err != nil {
+		return err
+	}
+
+	if err = initProgress(args); err != nil {
+		return err
+	}
+
+	if err = initUnclassified(args); err != nil {
+		return err
+	}
+
+	if err = initTypeDirs(args); err != nil {
+		return err
+	}
+
+	strictTypes = args.Strict
+	skipMatviewData = args.SkipMatviewData
+	keepPasswords = args.KeepPasswords
+
 	// Iterate over each line
 	for scanner.Scan() {
 		lineno = lineno + 1
 		line := scanner.Text()
+		progress.tick(&line, dbname)
 
-		// Skip restrict/unrestrict commands
-		if rgx_restrict.MatchString(line) {
+		// Skip restrict/unrestrict commands, unless the version of pg_dump is known to not produce them
+		if rgx_restrict.MatchString(line) && dumpinfo.supportsRestrict() {
 			continue
 		}
 
@@ -187,11 +296,18 @@ func ProcessStream(args *Config, scanner *bufio.Scanner) error {
 		if db := InitDatabaseFromLine(&line); db != "" {
 
 			dbname = db
+			bindDumpInfo(dbname)
+			stats.addDatabase(dbname, enableCurrentDb(dbname))
+			output.Log.Debug("connect database", "line", lineno, "database", dbname, "enabled", enableCurrentDb(dbname))
 
 			if err := Save(&curObj); err != nil {
 				return err
 			}
 
+			if err := flushDeferred(); err != nil {
+				return err
+			}
+
 			// init of the obj
 			curObj.init(args.AclFiles)
 
@@ -211,6 +327,9 @@ func ProcessStream(args *Config, scanner *bufio.Scanner) error {
 
 			if obj := InitRoleObjFromLine(&line, args, dbname); obj != nil {
 
+				obj.Line = lineno
+				output.Log.Debug("object header", "line", lineno, "type", obj.ObjType, "name", obj.Name)
+
 				if err := Save(&curObj); err != nil {
 					return err
 				}
@@ -234,31 +353,59 @@ func ProcessStream(args *Config, scanner *bufio.Scanner) error {
 		//
 		retmode := EndOfCluster(&line, args, dbname)
 
+		if retmode >= 0 {
+			output.Log.Debug("section boundary", "line", lineno, "retmode", retmode, "database", dbname)
+		}
+
 		if retmode == 2 {
 			clusterphase = false
 		}
 
+		// objects held back for grouping are stored before roles might be relocated
+		if retmode >= 0 {
+
+			if err := Save(&curObj); err != nil {
+				return err
+			}
+
+			curObj.init(args.AclFiles)
+
+			if err := flushDeferred(); err != nil {
+				return err
+			}
+		}
+
+		// roles are relocated once the database is complete, so usage of roles by all its objects is known
 		if retmode == 2 {
+			dbopen = true
+		} else if retmode == 0 && dbopen {
+
+			dbopen = false
 
 			if args.MvRl && dbname != "" && enableCurrentDb(dbname) {
 				if err := RelocateClusterRoles(args.Dest, dbname); err != nil {
 					return err
 				}
 			}
+
+			if visiting != nil && visiting.DatabaseEnd != nil && processdb {
+				if err := visiting.DatabaseEnd(dbname); err != nil {
+					return err
+				}
+			}
 		}
 
 		if retmode >= 0 {
 
-			if err := Save(&curObj); err != nil {
-				return err
+			// beginning of a database dump starts collecting its metadata, the end writes them down
+			if retmode == 2 && MatchDbStartEnd(&line) == 2 {
+				startDumpInfo(dbname)
+			} else if retmode == 0 {
+				if err := finishDumpInfo(args); err != nil {
+					return err
+				}
 			}
 
-			curObj.init(args.AclFiles)
-
-			continue
-		}
-
-		if !clusterphase && !processdb {
 			continue
 		}
 
@@ -269,8 +416,27 @@ func ProcessStream(args *Config, scanner *bufio.Scanner) error {
 		// Starts collecting data for obj type ROLE
 		// If type is TABLE DATA, data are not being added to the object (for performance reasons)
 		obj := InitCommonObjFromLine(&line, args, dbname)
+
+		// A database dumped with --create is connected after its DATABASE object,
+		// so the object decides whether the database is processed, not the previous (maybe skipped) one
+		if obj != nil && obj.ObjType == "DATABASE" && !clusterphase {
+			processdb = enableCurrentDb(obj.Name)
+		}
+
+		// objects of skipped databases are only counted
+		if !clusterphase && !processdb {
+			if obj != nil {
+				obj.Line = lineno
+				excludeObject(obj, "database")
+			}
+			continue
+		}
+
 		if obj != nil {
 
+			obj.Line = lineno
+			output.Log.Debug("object header", "line", lineno, "type", obj.ObjType, "name", obj.Name, "schema", obj.Schema, "database", dbname)
+
 			if err := Save(&curObj); err != nil {
 				return err
 			}
@@ -282,6 +448,18 @@ func ProcessStream(args *Config, scanner *bufio.Scanner) error {
 		if curObj.ObjType != "" && curObj.ObjType != "TABLE DATA" {
 
 			curObj.appendContent(&line)
+
+			// statements of a globals section keep their own line numbers once the section is split
+			if curObj.section != "" {
+				curObj.sectionLines = append(curObj.sectionLines, lineno)
+			}
+
+		} else if curObj.ObjType == "" {
+
+			// Lines preceding the first object contain metadata of the dump, any other content belongs to no object
+			if dumpinfo == nil || !dumpinfo.parseLine(&line) {
+				unclassified.addLine(dbname, lineno, &line)
+			}
 		}
 
 	}
@@ -291,8 +469,20 @@ func ProcessStream(args *Config, scanner *bufio.Scanner) error {
 		return err
 	}
 
-	// at end of the file, move roles to db location if requested
-	if args.MvRl && dbname != "" && enableCurrentDb(dbname) {
+	if err := flushDeferred(); err != nil {
+		return err
+	}
+
+	// metadata of the last database in case of incomplete dump
+	if err := finishDumpInfo(args); err != nil {
+		return err
+	}
+
+	stats.LinesRead = lineno
+	progress.finish(dbname)
+
+	// at end of the file, move roles to db location if requested, in case of incomplete dump
+	if dbopen && args.MvRl && dbname != "" && enableCurrentDb(dbname) {
 		if err := RelocateClusterRoles(args.Dest, dbname); err != nil {
 			return err
 		}
@@ -385,15 +575,17 @@ func InitRoleObjFromLine(line *string, args *Config, dbname string) *DbObject {
 		}
 	}
 
+	// settings of a single role are named after the role, other sections are split later
 	var objtype string
 	if result["Type1"] != "" {
 		objtype = result["Type1"]
 	} else if result["Type2"] != "" {
-		objtype = result["Type2"]
+		objtype = profiles.mapRole(result["Role"])
 	}
 
 	return &DbObject{
 		Name:     objtype,
+		section:  result["Type1"],
 		ObjType:  "ROLE",
 		Schema:   "-",
 		Database: dbname,
@@ -430,6 +622,7 @@ func InitCommonObjFromLine(line *string, args *Config, dbname string) *DbObject
 		Name:     result["Name"],
 		ObjType:  result["Type"],
 		Schema:   result["Schema"],
+		Owner:    result["Owner"],
 		Database: dbname,
 		AclFiles: args.AclFiles,
 		Paths: DbObjPath{
@@ -464,14 +657,70 @@ func preserveNewlines(data []byte, atEOF bool) (advance int, token []byte, err e
 
 func Save(dbo *DbObject) error {
 
+	// lines found outside of any object end where the object (or a section of the dump) starts
+	if err := unclassified.flushOrphans(); err != nil {
+		return err
+	}
+
+	if dbo.section != "" {
+
+		objs, err := splitGlobalsSection(dbo)
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range objs {
+			if err := saveObject(obj); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return saveObject(dbo)
+}
+
+func saveObject(dbo *DbObject) error {
+
+	recordView(dbo)
+
 	if !allowObject(dbo) {
 		return nil
 	}
 
+	if visiting != nil {
+		if dbo.ObjType == "" {
+			return nil
+		}
+		return visiting.Object(dbo)
+	}
+
+	hadcontent := dbo.Content.Len() > 0
+	dbo.applyProfiles()
+	dbo.redactPasswords()
+
 	if dbo.Content.Len() > 0 {
+
+		if dbo.ObjType == "ROLE" {
+			rolesUsage.recordRole(dbo)
+		} else {
+			rolesUsage.recordUsage(dbo)
+		}
+
+		if deferringObjects() {
+			deferObject(dbo)
+			return nil
+		}
+
+		stats.addObject(dbo)
 		return dbo.StoreObj()
 	}
 
+	if hadcontent {
+		excludeObject(dbo, "normalize")
+	}
+
 	return nil
 }
 
@@ -479,9 +728,23 @@ func Save(dbo *DbObject) error {
 func RelocateClusterRoles(destpath string, dbname string) error {
 
 	var srcloc = filepath.Join(destpath, "-")
-	var dstloc = filepath.Join(destpath, dbname, "-")
+	dstloc, err := resolveOutputPath(destpath, filepath.Join(destpath, EscapePathComponent(dbname), "-"))
+	if err != nil {
+		return err
+	}
+
+	// copy all the roles, unless only roles used by the database are requested
+	keep := func(path string) bool { return true }
+	if rolesUsage != nil {
+		keep = rolesUsage.relocationFilter(dbname)
+	}
+
+	if dryrun != nil {
+		dryrun.planCopyDir(srcloc, dstloc, keep)
+		return nil
+	}
 
-	if err := fu.CopyDir(srcloc, dstloc); err != nil {
+	if err := fu.CopyDirFiltered(srcloc, dstloc, keep, mcLinkFile); err != nil {
 		return err
 	}
 
@@ -0,0 +1,215 @@
+package dbobject
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var rgx_profileOwner *regexp.Regexp
+var rgx_profileTablespace *regexp.Regexp
+var rgx_profileRoleStmt *regexp.Regexp
+var rgx_profileRoleRef *regexp.Regexp
+var rgx_profileMembership *regexp.Regexp
+var rgx_profileRoleSetting *regexp.Regexp
+var rgx_profileRoleItem *regexp.Regexp
+
+// Keywords, which can't be used as role names without quotes
+var reservedKeywords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true, "as": true, "asc": true, "asymmetric": true,
+	"authorization": true, "binary": true, "both": true, "case": true, "cast": true, "check": true, "collate": true, "collation": true,
+	"column": true, "concurrently": true, "constraint": true, "create": true, "cross": true, "current_catalog": true, "current_date": true,
+	"current_role": true, "current_schema": true, "current_time": true, "current_timestamp": true, "current_user": true, "default": true,
+	"deferrable": true, "desc": true, "distinct": true, "do": true, "else": true, "end": true, "except": true, "false": true, "fetch": true,
+	"for": true, "foreign": true, "freeze": true, "from": true, "full": true, "grant": true, "group": true, "having": true, "ilike": true,
+	"in": true, "initially": true, "inner": true, "intersect": true, "into": true, "is": true, "isnull": true, "join": true, "lateral": true,
+	"leading": true, "left": true, "like": true, "limit": true, "localtime": true, "localtimestamp": true, "natural": true, "not": true,
+	"notnull": true, "null": true, "offset": true, "on": true, "only": true, "or": true, "order": true, "outer": true, "overlaps": true,
+	"placing": true, "primary": true, "references": true, "returning": true, "right": true, "select": true, "session_user": true,
+	"similar": true, "some": true, "symmetric": true, "system_user": true, "table": true, "tablesample": true, "then": true, "to": true,
+	"trailing": true, "true": true, "union": true, "unique": true, "user": true, "using": true, "variadic": true, "verbose": true,
+	"when": true, "where": true, "window": true, "with": true,
+}
+
+func init() {
+
+	rgx_profileOwner = regexp.MustCompile(`^ALTER .* OWNER TO .*;[\s]*$`)
+	rgx_profileTablespace = regexp.MustCompile(`^SET default_tablespace = .*;[\s]*$`)
+	rgx_profileRoleStmt = regexp.MustCompile(`^[\s]*(ALTER .* OWNER TO |GRANT |REVOKE |ALTER DEFAULT PRIVILEGES |CREATE ROLE |ALTER ROLE |COMMENT ON ROLE |CREATE POLICY |ALTER POLICY |CREATE USER MAPPING |ALTER USER MAPPING |CREATE SCHEMA .* AUTHORIZATION |SET ROLE |SET SESSION AUTHORIZATION )`)
+	// role lists follow these keywords in the statements above
+	rgx_profileRoleRef = regexp.MustCompile(`(\bOWNER TO |\bAUTHORIZATION |\bROLE |\bUSER MAPPING FOR |\bGRANTED BY |\bTO |\bFROM )(` + rgxRoleList + `)`)
+	// granted roles of memberships, ie `GRANT app_prod TO bob`, unlike privileges followed by ON
+	rgx_profileMembership = regexp.MustCompile(`^[\s]*(GRANT |REVOKE )(` + rgxRoleList + `)(?: TO | FROM )`)
+	// values of role settings aren't roles, ie `ALTER ROLE app SET search_path TO app_prod`
+	rgx_profileRoleSetting = regexp.MustCompile(`^[\s]*ALTER ROLE .* SET `)
+	rgx_profileRoleItem = regexp.MustCompile(`"(?:[^"]|"")+"|[^\s;,"]+`)
+}
+
+// Normalization profiles applied to the content of db objects before storing them.
+// They strip environment specific noise, so structures dumped from different environments can be compared.
+type normProfiles struct {
+	noOwner       bool
+	noTablespaces bool
+	noPrivileges  bool
+	roleMap       map[string]string
+}
+
+var profiles normProfiles
+
+// Parses profiles given by a comma separated list (ie `owner,tablespace,privileges`)
+// and role mapping given as comma separated list of `from=to` pairs (ie `app_prod=app,app_stage=app`)
+func initProfiles(normalize string, rolemap string) error {
+
+	profiles = normProfiles{}
+
+	for _, name := range strings.Split(normalize, ",") {
+
+		switch strings.TrimSpace(name) {
+		case "":
+		case "owner":
+			profiles.noOwner = true
+		case "tablespace":
+			profiles.noTablespaces = true
+		case "privileges":
+			profiles.noPrivileges = true
+		default:
+			return fmt.Errorf("unknown normalization profile: %s", name)
+		}
+	}
+
+	if rolemap == "" {
+		return nil
+	}
+
+	profiles.roleMap = make(map[string]string)
+
+	for _, pair := range strings.Split(rolemap, ",") {
+
+		from, to, found := strings.Cut(pair, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+
+		if !found || from == "" || to == "" {
+			return fmt.Errorf("invalid role mapping: %s", pair)
+		}
+
+		profiles.roleMap[unquoteIdent(from)] = unquoteIdent(to)
+	}
+
+	return nil
+}
+
+// Checks whether any profile modifying content is enabled
+func (p *normProfiles) enabled() bool {
+	return p.noOwner || p.noTablespaces || p.noPrivileges || p.roleMap != nil
+}
+
+// Maps role name according to configured role mapping
+func (p *normProfiles) mapRole(name string) string {
+
+	if mapped, ok := p.roleMap[strings.Trim(name, `"`)]; ok {
+		return mapped
+	}
+
+	return name
+}
+
+// Applies configured normalization profiles to the content of the object
+func (dbo *DbObject) applyProfiles() {
+
+	if !profiles.enabled() {
+		return
+	}
+
+	if profiles.noPrivileges && (dbo.ObjType == "ACL" || dbo.ObjType == "DEFAULT ACL") {
+		dbo.Content.Reset()
+		return
+	}
+
+	var sb strings.Builder
+
+	for _, line := range strings.SplitAfter(dbo.Content.String(), "\n") {
+
+		if profiles.noOwner && rgx_profileOwner.MatchString(line) {
+			continue
+		}
+
+		if profiles.noTablespaces && rgx_profileTablespace.MatchString(line) {
+			continue
+		}
+
+		if profiles.roleMap != nil && rgx_profileRoleStmt.MatchString(line) {
+			line = mapRolesInLine(line)
+		}
+
+		sb.WriteString(line)
+	}
+
+	dbo.Content.Reset()
+	dbo.Content.WriteString(sb.String())
+}
+
+// Replaces role names found in the line by their mapped values, quoted the way pg_dump quotes identifiers.
+// Only names in role positions are replaced (owner, grantee, granted role, ...), ie not the column `app_prod` in `GRANT SELECT(app_prod)`.
+// Names are replaced only if they form a whole identifier, ie `app_prod` is not replaced in `app_prod_ro`.
+func mapRolesInLine(line string) string {
+
+	var lists [][]int
+
+	if m := rgx_profileMembership.FindStringSubmatchIndex(line); m != nil {
+		lists = append(lists, m[4:6])
+	}
+
+	setting := rgx_profileRoleSetting.MatchString(line)
+
+	for _, m := range rgx_profileRoleRef.FindAllStringSubmatchIndex(line, -1) {
+
+		// m[2]:m[3] is the keyword, m[4]:m[5] the list of roles
+		if setting && line[m[2]:m[3]] != "ROLE " {
+			continue
+		}
+
+		lists = append(lists, m[4:6])
+	}
+
+	sort.Slice(lists, func(i, j int) bool { return lists[i][0] < lists[j][0] })
+
+	var sb strings.Builder
+	var pos int
+
+	for _, list := range lists {
+
+		if list[0] < pos {
+			continue
+		}
+
+		sb.WriteString(line[pos:list[0]])
+		sb.WriteString(rgx_profileRoleItem.ReplaceAllStringFunc(line[list[0]:list[1]], mapRoleIdent))
+		pos = list[1]
+	}
+
+	sb.WriteString(line[pos:])
+
+	return sb.String()
+}
+
+// Maps the role name given as an identifier of SQL code, returns the identifier of the mapped role
+func mapRoleIdent(ident string) string {
+
+	mapped, ok := profiles.roleMap[unquoteIdent(ident)]
+	if !ok {
+		return ident
+	}
+
+	return quoteIdent(mapped)
+}
+
+// Quotes the identifier, unless it's a lower case name which isn't a keyword
+func quoteIdent(name string) string {
+
+	if rgx_plainIdent.MatchString(name) && !reservedKeywords[name] {
+		return name
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,107 @@
+package dbobject
+
+import (
+	"testing"
+)
+
+func TestProfilesOwnerAndTablespace(t *testing.T) {
+
+	if err := initProfiles("owner,tablespace", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	dbo := DbObject{ObjType: "TABLE", Name: "orders", Schema: "public"}
+	dbo.Content.WriteString("--\n\nSET default_tablespace = '';\n\nCREATE TABLE public.orders (\n    id integer\n);\n\n\nALTER TABLE public.orders OWNER TO app_prod;\n\n--\n")
+
+	dbo.applyProfiles()
+
+	want := "--\n\n\nCREATE TABLE public.orders (\n    id integer\n);\n\n\n\n--\n"
+	if got := dbo.Content.String(); want != got {
+		t.Errorf("got %q, wants %q", got, want)
+	}
+}
+
+func TestProfilesPrivileges(t *testing.T) {
+
+	if err := initProfiles("privileges", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	dbo := DbObject{ObjType: "ACL", Name: "TABLE orders", Schema: "public"}
+	dbo.Content.WriteString("GRANT SELECT ON TABLE public.orders TO app_ro;\n")
+
+	dbo.applyProfiles()
+
+	if dbo.Content.Len() != 0 {
+		t.Errorf("ACL content expected to be dropped")
+	}
+
+	if err := initProfiles("owners", ""); err == nil {
+		t.Errorf("expected error for unknown profile")
+	}
+}
+
+func TestProfilesRoleMap(t *testing.T) {
+
+	if err := initProfiles("", "app_prod=app, app_stage=app"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"ALTER TABLE public.orders OWNER TO app_prod;\n":                    "ALTER TABLE public.orders OWNER TO app;\n",
+		"GRANT SELECT ON TABLE public.orders TO app_stage, app_prod_ro;\n":  "GRANT SELECT ON TABLE public.orders TO app, app_prod_ro;\n",
+		"REVOKE ALL ON SCHEMA public FROM \"app_prod\";\n":                  "REVOKE ALL ON SCHEMA public FROM app;\n",
+		"ALTER DEFAULT PRIVILEGES FOR ROLE app_prod IN SCHEMA public GRANT": "ALTER DEFAULT PRIVILEGES FOR ROLE app IN SCHEMA public GRANT",
+		"    app_prod integer,\n":                                           "    app_prod integer,\n",
+		// column of the same name as the role isn't mapped
+		"GRANT SELECT(app_prod) ON TABLE public.orders TO app_prod;\n": "GRANT SELECT(app_prod) ON TABLE public.orders TO app;\n",
+		"GRANT app_prod TO bob GRANTED BY app_stage;\n":                "GRANT app TO bob GRANTED BY app;\n",
+		"CREATE USER MAPPING FOR app_prod SERVER files;\n":             "CREATE USER MAPPING FOR app SERVER files;\n",
+		"ALTER ROLE app_prod SET search_path TO app_prod, public;\n":   "ALTER ROLE app SET search_path TO app_prod, public;\n",
+	}
+
+	for src, want := range cases {
+
+		dbo := DbObject{ObjType: "TABLE"}
+		dbo.Content.WriteString(src)
+		dbo.applyProfiles()
+
+		if got := dbo.Content.String(); want != got {
+			t.Errorf("got %q, wants %q", got, want)
+		}
+	}
+
+	if err := initProfiles("", "app_prod"); err == nil {
+		t.Errorf("expected error for invalid role mapping")
+	}
+}
+
+// Mapped names are quoted like pg_dump quotes them, names to be mapped are recognized quoted
+func TestProfilesRoleMapQuoting(t *testing.T) {
+
+	if err := initProfiles("", "app_prod=Data Team,Data Ops=AppOwner,app_stage=user"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"ALTER TABLE public.orders OWNER TO app_prod;\n":                 "ALTER TABLE public.orders OWNER TO \"Data Team\";\n",
+		"GRANT SELECT ON TABLE public.orders TO \"Data Ops\", app_ro;\n": "GRANT SELECT ON TABLE public.orders TO \"AppOwner\", app_ro;\n",
+		"CREATE SCHEMA app AUTHORIZATION app_stage;\n":                   "CREATE SCHEMA app AUTHORIZATION \"user\";\n",
+		"CREATE POLICY p ON public.orders TO app_prod USING (true);\n":   "CREATE POLICY p ON public.orders TO \"Data Team\" USING (true);\n",
+	}
+
+	for src, want := range cases {
+
+		dbo := DbObject{ObjType: "TABLE"}
+		dbo.Content.WriteString(src)
+		dbo.applyProfiles()
+
+		if got := dbo.Content.String(); want != got {
+			t.Errorf("got %q, wants %q", got, want)
+		}
+	}
+
+	if got := profiles.mapRole(`"Data Ops"`); got != "AppOwner" {
+		t.Errorf("got %s, wants AppOwner", got)
+	}
+}
@@ -0,0 +1,154 @@
+package dbobject
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"pgdump_splitter/output"
+	"time"
+)
+
+// Number of lines processed between checks of the clock
+const progressCheckLines = 4096
+
+// Reports progress of processing to stderr.
+// In `tty` mode a single line is continuously rewritten, in `log` mode a new line is printed periodically (suitable for CI logs).
+type progressReporter struct {
+	w        io.Writer
+	logmode  bool
+	interval time.Duration
+	total    int64
+	bytes    int64
+	lines    int
+	started  time.Time
+	last     time.Time
+}
+
+var progress *progressReporter
+
+// Sets up progress reporting.
+// Mode `auto` enables the reporting only if stderr is a terminal and quiet mode is not set.
+func initProgress(args *Config) error {
+
+	progress = nil
+
+	mode := args.Progress
+	if mode == "" || mode == "auto" {
+		mode = "off"
+		if !args.Quiet && output.IsTerminal(os.Stderr) {
+			mode = "tty"
+		}
+	}
+
+	interval := args.ProgressInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	switch mode {
+	case "off":
+		return nil
+	case "tty":
+		interval = 200 * time.Millisecond
+	case "log":
+	default:
+		return fmt.Errorf("invalid value of progress mode: %s", args.Progress)
+	}
+
+	progress = &progressReporter{w: os.Stderr, logmode: mode == "log", interval: interval, started: time.Now()}
+	progress.last = progress.started
+
+	if args.File != "" {
+		if info, err := os.Stat(args.File); err == nil {
+			progress.total = info.Size()
+		}
+	}
+
+	return nil
+}
+
+// Records processed line and reports progress if the interval has elapsed
+func (pr *progressReporter) tick(line *string, dbname string) {
+
+	if pr == nil {
+		return
+	}
+
+	pr.bytes += int64(len(*line))
+	pr.lines++
+
+	if pr.lines%progressCheckLines != 0 {
+		return
+	}
+
+	if now := time.Now(); now.Sub(pr.last) >= pr.interval {
+		pr.last = now
+		pr.report(dbname, false)
+	}
+}
+
+// Prints final state of the progress
+func (pr *progressReporter) finish(dbname string) {
+
+	if pr == nil {
+		return
+	}
+
+	pr.report(dbname, true)
+}
+
+func (pr *progressReporter) report(dbname string, final bool) {
+
+	var objects int
+	if stats != nil {
+		objects = stats.Objects
+	}
+
+	elapsed := time.Since(pr.started).Round(time.Second)
+
+	if pr.logmode {
+
+		fmt.Fprintf(pr.w, "progress bytes=%d", pr.bytes)
+		if pr.total > 0 {
+			fmt.Fprintf(pr.w, " total=%d percent=%.1f", pr.total, pr.percent())
+		}
+		fmt.Fprintf(pr.w, " lines=%d database=%q objects=%d elapsed=%s final=%t\n", pr.lines, dbname, objects, elapsed, final)
+
+		return
+	}
+
+	fmt.Fprintf(pr.w, "\r\033[K%s", formatBytes(pr.bytes))
+	if pr.total > 0 {
+		fmt.Fprintf(pr.w, " / %s (%.1f%%)", formatBytes(pr.total), pr.percent())
+	}
+	if dbname != "" {
+		fmt.Fprintf(pr.w, "  database: %s", dbname)
+	}
+	fmt.Fprintf(pr.w, "  objects: %d  %s", objects, elapsed)
+
+	if final {
+		fmt.Fprintln(pr.w)
+	}
+}
+
+func (pr *progressReporter) percent() float64 {
+	return float64(pr.bytes) * 100 / float64(pr.total)
+}
+
+// Formats size in bytes to a human readable form
+func formatBytes(n int64) string {
+
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,175 @@
+package dbobject
+
+import (
+	"regexp"
+	"strings"
+)
+
+// role name, either plain or quoted
+const rgxRoleName = `("(?:[^"]|"")+"|[^\s;,"]+)`
+
+// comma separated list of role names, not captured
+const rgxRoleList = `(?:"(?:[^"]|"")+"|[^\s;,"]+)(?:, ?(?:"(?:[^"]|"")+"|[^\s;,"]+))*`
+
+var rgx_roleStmt *regexp.Regexp
+var rgx_roleGrant *regexp.Regexp
+
+func init() {
+	rgx_roleStmt = regexp.MustCompile(`^(?:CREATE ROLE|ALTER ROLE|COMMENT ON ROLE|SECURITY LABEL FOR \S+ ON ROLE) ` + rgxRoleName)
+	rgx_roleGrant = regexp.MustCompile(`^GRANT ` + rgxRoleList + ` TO ` + rgxRoleName)
+}
+
+// Section of globals written by pg_dumpall, found by its header comment (ie `-- Roles`)
+type globalsSection struct {
+	objtype string
+	// name of the object, if the section is not split
+	name string
+	// returns the name of the object the statement belongs to; nil if the section is not split
+	split func(line string) (string, bool)
+	// names of the objects are role names, which are subject of role mapping
+	roles bool
+}
+
+// Sections of pg_dumpall globals, keyed by their header
+var globalsSections = map[string]globalsSection{
+	"Roles":            {objtype: "ROLE", split: roleOfStatement, roles: true},
+	"Role memberships": {objtype: "ROLE", split: roleOfStatement, roles: true},
+}
+
+// Returns the role a statement of the roles section belongs to.
+// Memberships (`GRANT role TO member`) belong to the member.
+func roleOfStatement(line string) (string, bool) {
+
+	if m := rgx_roleStmt.FindStringSubmatch(line); m != nil {
+		return unquoteIdent(m[1]), true
+	}
+
+	if m := rgx_roleGrant.FindStringSubmatch(line); m != nil {
+		return unquoteIdent(m[1]), true
+	}
+
+	return "", false
+}
+
+// Splits a section of pg_dumpall globals into objects, one per role, tablespace etc.
+// Lines not starting a statement (ie continuation of a multiline comment) stay with the preceding statement.
+// Statements which don't belong to any object (ie `SELECT pg_catalog.binary_upgrade_set_next_pg_authid_oid(...)` written with --binary-upgrade)
+// go to the object of the statement following them. If none follows, they are unclassified content.
+func splitGlobalsSection(section *DbObject) ([]*DbObject, error) {
+
+	gs := globalsSections[section.section]
+
+	if gs.split == nil {
+		obj := section.globalsObject(gs, gs.name, section.Line)
+		obj.Content.WriteString(section.Content.String())
+		return []*DbObject{obj}, nil
+	}
+
+	var objs []*DbObject
+	index := make(map[string]*DbObject)
+	var current *DbObject
+
+	// lines waiting for the statement they precede, and their line numbers
+	var pending []string
+	var pendingLines []int
+
+	// quote (of a string or an identifier) open at the end of the previous line, and whether the statement is complete
+	var quote byte
+	complete := true
+
+	for i, line := range strings.SplitAfter(section.Content.String(), "\n") {
+
+		lineno := section.Line
+		if i < len(section.sectionLines) {
+			lineno = section.sectionLines[i]
+		}
+
+		trimmed := strings.TrimSpace(line)
+		comment := complete && strings.HasPrefix(trimmed, "--")
+		statement := complete && trimmed != "" && !comment
+
+		if statement || !complete {
+			quote = openQuote(line, quote)
+			complete = quote == 0 && strings.HasSuffix(trimmed, ";")
+		}
+
+		if statement {
+			if name, found := gs.split(line); found {
+
+				current = index[name]
+
+				if current == nil {
+
+					if len(pending) > 0 {
+						lineno = pendingLines[0]
+					}
+
+					current = section.globalsObject(gs, name, lineno)
+					index[name] = current
+					objs = append(objs, current)
+				}
+
+				for _, p := range pending {
+					current.appendContent(&p)
+				}
+				pending, pendingLines = nil, nil
+
+				current.appendContent(&line)
+				continue
+			}
+		}
+
+		// unknown statements wait for the next statement, so do lines following them
+		if statement || len(pending) > 0 {
+
+			pending = append(pending, line)
+			pendingLines = append(pendingLines, lineno)
+			continue
+		}
+
+		if current != nil {
+			current.appendContent(&line)
+		}
+	}
+
+	// statements no object follows don't belong to any
+	for i := range pending {
+		unclassified.addLine(section.Database, pendingLines[i], &pending[i])
+	}
+
+	return objs, unclassified.flushOrphans()
+}
+
+// Returns the quote character open at the end of the line, given the one open at its start. Zero if none is open.
+// Strings (`'`) and quoted identifiers (`"`) are recognized, doubled quotes inside them close and reopen them.
+func openQuote(line string, quote byte) byte {
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case quote == 0 && (line[i] == '\'' || line[i] == '"'):
+			quote = line[i]
+		case quote != 0 && line[i] == quote:
+			quote = 0
+		}
+	}
+
+	return quote
+}
+
+// Creates an object of the globals section, starting on given line
+func (section *DbObject) globalsObject(gs globalsSection, name string, line int) *DbObject {
+
+	if gs.roles {
+		name = profiles.mapRole(name)
+	}
+
+	return &DbObject{
+		Name:     name,
+		ObjType:  gs.objtype,
+		Schema:   section.Schema,
+		Database: section.Database,
+		AclFiles: section.AclFiles,
+		Paths:    section.Paths,
+		Line:     line,
+	}
+}
@@ -0,0 +1,345 @@
+package dbobject
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Cluster part of the plain dump created by pg_dumpall 16
+const testDumpRoles = `--
+-- PostgreSQL database cluster dump
+--
+
+SET default_transaction_read_only = off;
+
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+
+--
+-- Roles
+--
+
+CREATE ROLE app;
+ALTER ROLE app WITH NOSUPERUSER INHERIT NOCREATEROLE NOCREATEDB LOGIN NOREPLICATION NOBYPASSRLS;
+CREATE ROLE app_ro;
+ALTER ROLE app_ro WITH NOSUPERUSER INHERIT NOCREATEROLE NOCREATEDB NOLOGIN NOREPLICATION NOBYPASSRLS;
+COMMENT ON ROLE app_ro IS 'read only access;
+granted to reporting';
+CREATE ROLE "Data Team";
+ALTER ROLE "Data Team" WITH NOSUPERUSER INHERIT NOCREATEROLE NOCREATEDB NOLOGIN NOREPLICATION NOBYPASSRLS;
+
+--
+-- User Configurations
+--
+
+--
+-- User Config "app"
+--
+
+ALTER ROLE app SET search_path TO 'app', 'public';
+
+
+--
+-- Role memberships
+--
+
+GRANT app_ro TO app WITH INHERIT TRUE GRANTED BY postgres;
+GRANT app_ro TO "Data Team" WITH INHERIT TRUE GRANTED BY postgres;
+
+
+
+
+--
+-- Databases
+--
+
+`
+
+func TestRolesPerFile(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom"}, testDumpRoles))
+
+	if len(tree) != 3 {
+		t.Errorf("expected one file per role, got: %v", tree)
+	}
+
+	app := tree["-/role/app.sql"]
+
+	for _, want := range []string{
+		"CREATE ROLE app;",
+		"ALTER ROLE app WITH NOSUPERUSER",
+		"ALTER ROLE app SET search_path",
+		"GRANT app_ro TO app WITH INHERIT TRUE",
+	} {
+		if !strings.Contains(app, want) {
+			t.Errorf("role file does not contain %s, got: %s", want, app)
+		}
+	}
+
+	if ro := tree["-/role/app_ro.sql"]; !strings.Contains(ro, "granted to reporting';") || strings.Contains(ro, "GRANT") {
+		t.Errorf("unexpected content of role file: %s", ro)
+	}
+
+	if team := tree["-/role/Data Team.sql"]; !strings.Contains(team, `GRANT app_ro TO "Data Team"`) {
+		t.Errorf("membership should be stored in the member's file, got: %v", tree)
+	}
+}
+
+func TestRoleOfStatement(t *testing.T) {
+
+	for _, tc := range []struct {
+		line string
+		want string
+	}{
+		{`CREATE ROLE "Data Team";`, "Data Team"},
+		{`ALTER ROLE "a ""quoted"" role" WITH LOGIN;`, `a "quoted" role`},
+		{`GRANT app_ro TO app;`, "app"},
+		{`GRANT app_ro TO app WITH INHERIT TRUE GRANTED BY postgres;`, "app"},
+		{`GRANT app_ro TO app WITH ADMIN OPTION, INHERIT TRUE GRANTED BY "Grant TO All";`, "app"},
+		{`GRANT "Read TO All" TO "Data Team" WITH INHERIT TRUE GRANTED BY postgres;`, "Data Team"},
+		{`GRANT "Read TO All" TO "Write TO All" GRANTED BY "Admin TO";`, "Write TO All"},
+		{`GRANT app_ro, "Read TO All" TO "Data Team";`, "Data Team"},
+		{`GRANT app_ro TO "Data Team" GRANTED BY "x"" TO y";`, "Data Team"},
+	} {
+		got, ok := roleOfStatement(tc.line)
+		if !ok || got != tc.want {
+			t.Errorf("%s: got %q (%t), wants %q", tc.line, got, ok, tc.want)
+		}
+	}
+
+	for _, line := range []string{
+		`GRANT SET ON PARAMETER work_mem TO app;`,
+		`SELECT pg_catalog.binary_upgrade_set_next_pg_authid_oid('16384'::pg_catalog.oid);`,
+	} {
+		if got, ok := roleOfStatement(line); ok {
+			t.Errorf("%s: should not belong to a role, got %q", line, got)
+		}
+	}
+}
+
+// pg_dumpall --binary-upgrade writes the oid of each role and tablespace before it's created
+func TestRolesBinaryUpgrade(t *testing.T) {
+
+	dump := `--
+-- Roles
+--
+
+
+-- For binary upgrade, must preserve pg_authid.oid
+SELECT pg_catalog.binary_upgrade_set_next_pg_authid_oid('16384'::pg_catalog.oid);
+
+CREATE ROLE app;
+ALTER ROLE app WITH NOSUPERUSER INHERIT NOCREATEROLE NOCREATEDB LOGIN NOREPLICATION NOBYPASSRLS;
+
+-- For binary upgrade, must preserve pg_authid.oid
+SELECT pg_catalog.binary_upgrade_set_next_pg_authid_oid('16385'::pg_catalog.oid);
+
+CREATE ROLE app_ro;
+ALTER ROLE app_ro WITH NOSUPERUSER INHERIT NOCREATEROLE NOCREATEDB NOLOGIN NOREPLICATION NOBYPASSRLS;
+COMMENT ON ROLE app_ro IS 'read only access;
+SELECT granted to reporting';
+
+--
+-- Tablespaces
+--
+
+SELECT pg_catalog.pg_size_pretty(0);
+CREATE TABLESPACE archive OWNER app LOCATION '/var/lib/postgresql/archive';
+
+
+`
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom"}, dump))
+
+	for path, want := range map[string]string{
+		"-/role/app.sql":           "SELECT pg_catalog.binary_upgrade_set_next_pg_authid_oid('16384'::pg_catalog.oid);\n\nCREATE ROLE app;\n",
+		"-/role/app_ro.sql":        "SELECT pg_catalog.binary_upgrade_set_next_pg_authid_oid('16385'::pg_catalog.oid);\n\nCREATE ROLE app_ro;\n",
+		"-/tablespace/archive.sql": "SELECT pg_catalog.pg_size_pretty(0);\nCREATE TABLESPACE archive ",
+	} {
+		if got := tree[path]; !strings.HasPrefix(got, want) {
+			t.Errorf("%s: got %q, wants prefix %q", path, got, want)
+		}
+	}
+
+	if app := tree["-/role/app.sql"]; strings.Contains(app, "16385") {
+		t.Errorf("statement of the following role stored with app: %q", app)
+	}
+
+	// lines of a multiline string stay with their statement
+	if ro := tree["-/role/app_ro.sql"]; !strings.HasSuffix(ro, "SELECT granted to reporting';\n") {
+		t.Errorf("unexpected content of role file: %q", ro)
+	}
+
+	// objects start on the line of their first statement
+	entries := listTestDump(t, Config{}, dump).Entries
+	if len(entries) != 3 {
+		t.Errorf("expected 3 objects, got: %v", entries)
+	}
+
+	for _, e := range entries {
+		if line := strings.Split(dump, "\n")[e.Line-1]; !strings.HasPrefix(line, "SELECT ") {
+			t.Errorf("%s %s: unexpected line %d: %q", e.Type, e.Name, e.Line, line)
+		}
+	}
+}
+
+// statements of a globals section no role follows are unclassified content, not lost
+func TestRolesUnclassified(t *testing.T) {
+
+	dump := `--
+-- Roles
+--
+
+CREATE ROLE app;
+SELECT pg_catalog.binary_upgrade_set_next_pg_authid_oid('16385'::pg_catalog.oid);
+
+--
+-- Tablespaces
+--
+
+-- For binary upgrade, must preserve pg_tablespace.oid
+SELECT pg_catalog.binary_upgrade_set_next_pg_tablespace_oid('16386'::pg_catalog.oid);
+
+
+`
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", Unclassified: "file"}, dump))
+
+	if stats.Unclassified[unclassifiedOrphan] != 2 {
+		t.Errorf("unexpected unclassified counts: %v", stats.Unclassified)
+	}
+
+	if app := tree["-/role/app.sql"]; strings.Contains(app, "SELECT") {
+		t.Errorf("statement following the last role stored with it: %q", app)
+	}
+
+	content := tree[unclassifiedFileName]
+	for _, want := range []string{
+		"-- line 6: orphan content\n--\n\nSELECT pg_catalog.binary_upgrade_set_next_pg_authid_oid('16385'",
+		"-- line 13: orphan content\n--\n\nSELECT pg_catalog.binary_upgrade_set_next_pg_tablespace_oid('16386'",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("unclassified content does not contain %q, got: %v", want, tree)
+		}
+	}
+}
+
+func TestRolesMapped(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", RoleMap: "app=application"}, testDumpRoles))
+
+	if app := tree["-/role/application.sql"]; !strings.Contains(app, "CREATE ROLE application;") || !strings.Contains(app, "ALTER ROLE application SET") {
+		t.Errorf("role file should be named after the mapped role, got: %v", tree)
+	}
+}
+
+func TestMcRolesUsed(t *testing.T) {
+
+	dump := testDumpRoles + testDumpCreate
+
+	// acls are excluded, so app_ro is relocated only as a role app is a member of
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", MvRl: true, McRoles: "used", ExOT: "^ACL$"}, dump))
+
+	for _, want := range []string{"shop/-/role/app.sql", "shop/-/role/app_ro.sql"} {
+		if _, found := tree[want]; !found {
+			t.Errorf("expected relocated role %s, got: %v", want, tree)
+		}
+	}
+
+	if _, found := tree["shop/-/role/Data Team.sql"]; found {
+		t.Errorf("role not used by the database should not be relocated")
+	}
+
+	tree = readTestTree(t, splitTestDump(t, Config{Mode: "custom", MvRl: true}, dump))
+
+	if _, found := tree["shop/-/role/Data Team.sql"]; !found {
+		t.Errorf("all roles should be relocated by default, got: %v", tree)
+	}
+}
+
+func TestMcRelocatedWhenDatabaseCompletes(t *testing.T) {
+
+	// pg_dumpall connects to postgres database before its dump starts, the other databases are created first
+	postgres := `--
+-- Database "postgres" dump
+--
+
+\connect postgres
+
+--
+-- PostgreSQL database dump
+--
+
+SET statement_timeout = 0;
+
+--
+-- PostgreSQL database dump complete
+--
+
+`
+
+	dump := testDumpRoles + strings.ReplaceAll(testDumpCreate, "shop", "analytics") + postgres
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", MvRl: true, McRoles: "used", ExDb: "^postgres$"}, dump))
+
+	for _, want := range []string{"analytics/-/role/app.sql", "analytics/-/role/app_ro.sql"} {
+		if _, found := tree[want]; !found {
+			t.Errorf("expected relocated role %s, got: %v", want, tree)
+		}
+	}
+
+	for path := range tree {
+		if strings.HasPrefix(path, "postgres/") || path == "analytics/-/role/Data Team.sql" {
+			t.Errorf("unexpected file %s", path)
+		}
+	}
+}
+
+func TestMcRolesWithDeferredObjects(t *testing.T) {
+
+	// the last object of the database is the only one granting to "Data Team"
+	dump := testDumpRoles + strings.Replace(testDumpCreate, "TO app_ro;", `TO "Data Team";`, 1)
+
+	// grouping of partitions holds objects (incl. roles) back until the end of the section, they are stored before roles are relocated
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", MvRl: true, McRoles: "used", Partitions: "parent"}, dump))
+
+	for _, want := range []string{"shop/-/role/app.sql", "shop/-/role/app_ro.sql", "shop/-/role/Data Team.sql"} {
+		if _, found := tree[want]; !found {
+			t.Errorf("expected relocated role %s, got: %v", want, tree)
+		}
+	}
+
+	if got := tree["shop/-/role/Data Team.sql"]; got != tree["-/role/Data Team.sql"] || got == "" {
+		t.Errorf("relocated role differs from the cluster one: %q", got)
+	}
+}
+
+func TestMcLink(t *testing.T) {
+
+	dump := testDumpRoles + testDumpCreate
+
+	dir := splitTestDump(t, Config{Mode: "custom", MvRl: true, McLink: "symlink"}, dump)
+
+	target, err := os.Readlink(filepath.Join(dir, "shop", "-", "role", "app.sql"))
+	if err != nil || target != filepath.Join("..", "..", "..", "-", "role", "app.sql") {
+		t.Errorf("expected relative symbolic link, got: %s, %v", target, err)
+	}
+
+	if app := readTestTree(t, dir)["shop/-/role/app.sql"]; !strings.Contains(app, "CREATE ROLE app;") {
+		t.Errorf("symbolic link should resolve to the role file, got: %s", app)
+	}
+
+	dir = splitTestDump(t, Config{Mode: "custom", MvRl: true, McLink: "hardlink"}, dump)
+
+	src, _ := os.Stat(filepath.Join(dir, "-", "role", "app.sql"))
+	dst, err := os.Stat(filepath.Join(dir, "shop", "-", "role", "app.sql"))
+	if err != nil || !os.SameFile(src, dst) {
+		t.Errorf("expected hard link of the role file, got: %v", err)
+	}
+
+	if keepClusterDir(&Config{McLink: "copy"}) || !keepClusterDir(&Config{McLink: "symlink"}) || !keepClusterDir(&Config{McKeep: true}) {
+		t.Errorf("unexpected decision about keeping the cluster directory")
+	}
+}
@@ -0,0 +1,237 @@
+package dbobject
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Identifier of an object given to the show command, ie `billing.invoice` or `billing.calc(integer, text)`
+type objectIdent struct {
+	schema  string
+	name    string
+	args    string
+	hasArgs bool
+}
+
+// Parses qualified identifier of an object. Objects not belonging to a schema (ie schemas, extensions) are given by name only.
+// Names might be quoted, so they contain dots or parentheses (ie `"my.schema"."t"`).
+// Arguments of functions are normalized the same way as names of function files are generated, so argument names might be included.
+func parseObjectIdent(ident string) (objectIdent, error) {
+
+	var id objectIdent
+	var parts []string
+
+	ident = strings.TrimSpace(ident)
+	quoted := false
+	start := 0
+
+	for i := 0; i < len(ident) && !id.hasArgs; i++ {
+
+		switch {
+		case ident[i] == '"':
+			quoted = !quoted
+		case quoted:
+		case ident[i] == '.':
+			parts = append(parts, ident[start:i])
+			start = i + 1
+		case ident[i] == '(':
+			if !strings.HasSuffix(ident, ")") {
+				return id, fmt.Errorf("invalid object identifier %s", ident)
+			}
+
+			id.hasArgs = true
+			id.args = NormalizeFunctionIdentArgs(ident[i+1 : len(ident)-1])
+			parts = append(parts, ident[start:i])
+		}
+	}
+
+	if quoted {
+		return id, fmt.Errorf("invalid object identifier %s", ident)
+	}
+
+	if !id.hasArgs {
+		parts = append(parts, ident[start:])
+	}
+
+	switch len(parts) {
+	case 1:
+		id.schema = "-"
+		id.name = unquoteIdent(parts[0])
+	case 2:
+		id.schema = unquoteIdent(parts[0])
+		id.name = unquoteIdent(parts[1])
+	default:
+		return id, fmt.Errorf("invalid object identifier %s", ident)
+	}
+
+	if id.name == "" || id.schema == "" {
+		return id, fmt.Errorf("invalid object identifier %s", ident)
+	}
+
+	return id, nil
+}
+
+// Tells whether the normalized object is the one identified (not a dependent object like an index or a comment)
+func (id *objectIdent) matches(dbo *DbObject) bool {
+
+	if dbo.ObjSubtype != "" || dbo.Schema != id.schema {
+		return false
+	}
+
+	fname, fargs := getFuncIdentParts(dbo.Name)
+
+	if fname == "" {
+		return !id.hasArgs && dbo.Name == id.name
+	}
+
+	return fname == id.name && (!id.hasArgs || NormalizeFunctionIdentArgs(fargs) == id.args)
+}
+
+// Tells whether the normalized object might be stored in the file of the identified object.
+// It only preselects objects held back until the identified object is found.
+func (id *objectIdent) candidate(dbo *DbObject) bool {
+	return dbo.Paths.NameForFile == id.name || strings.HasPrefix(dbo.Paths.NameForFile, id.name+"-")
+}
+
+// Collects objects stored in custom mode into the file of the identified object
+type objectCollector struct {
+	id    objectIdent
+	found *DbObject
+	objs  []*DbObject
+	// candidates seen before the object was found, by paths, and the database they belong to
+	pending  map[string][]*DbObject
+	database string
+	// order of the first object of every file of the database, and the last object stored into the file of the identified object
+	positions map[string]int
+	last      int
+}
+
+func (oc *objectCollector) visit(dbo *DbObject) error {
+
+	// dependents of the object are only found in the same database
+	if oc.found != nil && dbo.Database != oc.found.Database {
+		return ErrStopVisit
+	}
+
+	if oc.found == nil && dbo.Database != oc.database {
+		oc.database = dbo.Database
+		oc.pending = make(map[string][]*DbObject)
+		oc.positions = make(map[string]int)
+	}
+
+	dbo.redactPasswords()
+
+	obj := *dbo
+	obj.Content = strings.Builder{}
+	obj.Content.WriteString(dbo.Content.String())
+	obj.AclFiles = false
+	obj.Paths = DbObjPath{IsCustom: true}
+
+	obj.normalizeDbObject()
+	obj.generateDestinationPath()
+
+	if obj.Paths.FullPath == "" {
+		return nil
+	}
+
+	if oc.complete(&obj) {
+		return ErrStopVisit
+	}
+
+	if _, found := oc.positions[obj.Paths.FullPath]; !found && obj.ObjType != "ACL" {
+		oc.positions[obj.Paths.FullPath] = len(oc.positions)
+	}
+
+	if oc.found == nil {
+
+		if oc.id.matches(&obj) {
+			oc.found = &obj
+			oc.last = len(oc.positions) - 1
+			oc.objs = append(oc.pending[obj.Paths.FullPath], &obj)
+			oc.pending = nil
+		} else if oc.id.candidate(&obj) {
+			oc.pending[obj.Paths.FullPath] = append(oc.pending[obj.Paths.FullPath], &obj)
+		}
+
+		return nil
+	}
+
+	if obj.Paths.FullPath == oc.found.Paths.FullPath {
+		oc.objs = append(oc.objs, &obj)
+
+		if obj.ObjType != "ACL" {
+			oc.last = len(oc.positions) - 1
+		}
+	}
+
+	return nil
+}
+
+// Tells whether all objects stored into the file of the identified object have been collected, once the object is given.
+// pg_dump writes ACLs after all other objects of the database, in the order of objects they belong to.
+// So the ACL of an object following the last object of the file means that no more ACLs of the file follow.
+// Data of materialized views are written after ACLs, so the file of a materialized view is complete at the end of the database only.
+func (oc *objectCollector) complete(dbo *DbObject) bool {
+
+	if oc.found == nil || oc.found.ObjType == "MATERIALIZED VIEW" || dbo.ObjType != "ACL" {
+		return false
+	}
+
+	pos, found := oc.positions[dbo.Paths.FullPath]
+
+	return found && pos > oc.last
+}
+
+// Reads the dump until the identified object and objects stored along with it in custom mode are found,
+// and writes their content the same way as it's written to the file in custom mode.
+// Reading stops once ACLs of objects following them are found, or at the end of the database the object belongs to.
+func ShowStream(args *Config, scanner *bufio.Scanner, ident string, w io.Writer) error {
+
+	id, err := parseObjectIdent(ident)
+	if err != nil {
+		return err
+	}
+
+	oc := &objectCollector{id: id, pending: make(map[string][]*DbObject), positions: make(map[string]int)}
+
+	visitor := Visitor{
+		Object: oc.visit,
+		DatabaseEnd: func(dbname string) error {
+			if oc.found != nil {
+				return ErrStopVisit
+			}
+			return nil
+		},
+	}
+
+	if err := VisitStream(args, scanner, visitor); err != nil {
+		return err
+	}
+
+	if oc.found == nil {
+		return fmt.Errorf("object %s not found", ident)
+	}
+
+	for i, dbo := range oc.objs {
+
+		prefix := ""
+		if i > 0 {
+			prefix = "\n"
+		}
+
+		if _, err := io.WriteString(w, prefix+dbo.fileContent()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Shows the identified object of the dump given by the configuration
+func ShowDump(args *Config, ident string, w io.Writer) error {
+	return readDump(args, func(scanner *bufio.Scanner) error {
+		return ShowStream(args, scanner, ident, w)
+	})
+}
@@ -0,0 +1,295 @@
+package dbobject
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Part of the plain dump created by pg_dump 16 with overloaded functions
+const testDumpFunctions = `--
+-- PostgreSQL database dump
+--
+
+-- Dumped from database version 16.2
+-- Dumped by pg_dump version 16.3
+
+SET statement_timeout = 0;
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+SELECT pg_catalog.set_config('search_path', '', false);
+
+--
+-- Name: billing; Type: SCHEMA; Schema: -; Owner: app
+--
+
+CREATE SCHEMA billing;
+
+
+ALTER SCHEMA billing OWNER TO app;
+
+--
+-- Name: total(integer); Type: FUNCTION; Schema: billing; Owner: app
+--
+
+CREATE FUNCTION billing.total(p_invoice integer) RETURNS numeric
+    LANGUAGE sql
+    AS $$ SELECT 0::numeric $$;
+
+
+ALTER FUNCTION billing.total(p_invoice integer) OWNER TO app;
+
+--
+-- Name: total(integer, character varying); Type: FUNCTION; Schema: billing; Owner: app
+--
+
+CREATE FUNCTION billing.total(p_invoice integer, p_currency character varying DEFAULT 'EUR'::character varying) RETURNS numeric
+    LANGUAGE sql
+    AS $$ SELECT 1::numeric $$;
+
+
+ALTER FUNCTION billing.total(p_invoice integer, p_currency character varying) OWNER TO app;
+
+--
+-- Name: FUNCTION total(p_invoice integer, p_currency character varying); Type: COMMENT; Schema: billing; Owner: app
+--
+
+COMMENT ON FUNCTION billing.total(p_invoice integer, p_currency character varying) IS 'total in currency';
+
+
+--
+-- Name: invoice; Type: TABLE; Schema: billing; Owner: app
+--
+
+CREATE TABLE billing.invoice (
+    id integer NOT NULL,
+    total numeric(12,2)
+);
+
+
+ALTER TABLE billing.invoice OWNER TO app;
+
+--
+-- Name: invoice_total_idx; Type: INDEX; Schema: billing; Owner: app
+--
+
+CREATE INDEX invoice_total_idx ON billing.invoice USING btree (total);
+
+
+--
+-- Name: TABLE invoice; Type: ACL; Schema: billing; Owner: app
+--
+
+GRANT SELECT ON TABLE billing.invoice TO app_ro;
+
+
+--
+-- PostgreSQL database dump complete
+--
+
+`
+
+func showTestDump(t *testing.T, args Config, dump string, ident string) (string, error) {
+
+	t.Helper()
+
+	scanner := bufio.NewScanner(strings.NewReader(dump))
+	scanner.Split(preserveNewlines)
+
+	if err := IsExclObjTypeOk(args.ExOT); err != nil {
+		t.Fatal(err)
+	}
+
+	var shown bytes.Buffer
+	err := ShowStream(&args, scanner, ident, &shown)
+
+	return shown.String(), err
+}
+
+func TestShowObject(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", Prologue: "none"}, testDumpFunctions))
+
+	for ident, path := range map[string]string{
+		"billing":                "billing/billing.sql",
+		"billing.invoice":        "billing/table/invoice.sql",
+		"billing.total(integer)": "billing/function/total-" + funcArgsToHash("integer")[0:6] + ".sql",
+		"billing.total(p_invoice integer, character varying)": "billing/function/total-" + funcArgsToHash("integer, character varying")[0:6] + ".sql",
+		`"billing"."total"(integer, character varying)`:       "billing/function/total-" + funcArgsToHash("integer, character varying")[0:6] + ".sql",
+	} {
+
+		shown, err := showTestDump(t, Config{}, testDumpFunctions, ident)
+		if err != nil {
+			t.Errorf("%s: %v", ident, err)
+			continue
+		}
+
+		if expected, found := tree[path]; !found || shown != expected {
+			t.Errorf("%s: shown content differs from %s:\n%s\nexpected:\n%s", ident, path, shown, expected)
+		}
+	}
+
+	// without arguments the first overload is shown
+	if shown, _ := showTestDump(t, Config{}, testDumpFunctions, "billing.total"); !strings.Contains(shown, "p_invoice integer) RETURNS") {
+		t.Errorf("unexpected function shown: %s", shown)
+	}
+
+	if shown, _ := showTestDump(t, Config{ExOT: "^ACL$"}, testDumpFunctions, "billing.invoice"); strings.Contains(shown, "GRANT") || !strings.Contains(shown, "CREATE INDEX") {
+		t.Errorf("unexpected content of filtered object: %s", shown)
+	}
+
+	for _, ident := range []string{"billing.missing", "public.invoice", "billing.total(text)", "billing.invoice("} {
+		if _, err := showTestDump(t, Config{}, testDumpFunctions, ident); err == nil {
+			t.Errorf("%s: expected an error", ident)
+		}
+	}
+}
+
+func TestShowObjectStopsAtDatabaseEnd(t *testing.T) {
+
+	// the rest of the dump is never read, so it doesn't matter it's not a valid dump
+	dump := testDumpFunctions + "--\n-- Name: invoice; Type: TABLE; Schema: billing; Owner: app\n--\n\nCREATE TABLE billing.invoice ();\n"
+
+	shown, err := showTestDump(t, Config{}, dump, "billing.invoice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(shown, "CREATE TABLE") != 1 {
+		t.Errorf("objects after the end of the database shown: %s", shown)
+	}
+}
+
+func TestShowObjectStopsAfterAcls(t *testing.T) {
+
+	// ACLs follow the order of objects, so the ACL of the table means no more objects of the function follow
+	late := "--\n-- Name: FUNCTION total(p_invoice integer); Type: COMMENT; Schema: billing; Owner: app\n--\n\nCOMMENT ON FUNCTION billing.total(p_invoice integer) IS 'never read';\n\n\n"
+	dump := strings.Replace(testDumpFunctions, "--\n-- PostgreSQL database dump complete", late+"--\n-- PostgreSQL database dump complete", 1)
+
+	shown, err := showTestDump(t, Config{}, dump, "billing.total(integer)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(shown, "CREATE FUNCTION billing.total(p_invoice integer)") || strings.Contains(shown, "never read") {
+		t.Errorf("objects after ACLs of following objects shown: %s", shown)
+	}
+
+	// the table still gets its own ACL
+	if shown, _ := showTestDump(t, Config{}, dump, "billing.invoice"); !strings.Contains(shown, "GRANT SELECT") {
+		t.Errorf("ACL of the object not shown: %s", shown)
+	}
+}
+
+func TestParseObjectIdent(t *testing.T) {
+
+	cases := map[string]objectIdent{
+		"billing":                   {schema: "-", name: "billing"},
+		"billing.invoice":           {schema: "billing", name: "invoice"},
+		`"my.schema"."t"`:           {schema: "my.schema", name: "t"},
+		`"a""b"."Order (Lines)"`:    {schema: `a"b`, name: "Order (Lines)"},
+		`"my.schema".calc(integer)`: {schema: "my.schema", name: "calc", args: "integer", hasArgs: true},
+		`"f.(x)"(integer, text)`:    {schema: "-", name: "f.(x)", args: "integer, text", hasArgs: true},
+	}
+
+	for ident, want := range cases {
+
+		got, err := parseObjectIdent(ident)
+		if err != nil {
+			t.Errorf("%s: %v", ident, err)
+			continue
+		}
+
+		if got != want {
+			t.Errorf("%s: got %+v, wants %+v", ident, got, want)
+		}
+	}
+
+	for _, ident := range []string{"a.b.c", `"unterminated.t`, "billing.", `"".t`, "calc(integer"} {
+		if _, err := parseObjectIdent(ident); err == nil {
+			t.Errorf("%s: expected an error", ident)
+		}
+	}
+}
+
+// Quotes the identifier, so it's given to the show command the same way for any name
+func quoteTestIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Shows every object of the testdata corpus, which has a file of its own in custom mode,
+// and compares the output with the file of the expected structure
+func TestShowCorpus(t *testing.T) {
+
+	dumps, err := filepath.Glob(filepath.Join("testdata", "dumps", "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// databases are filtered the same way as the expected structures are
+	args := Config{Mode: "custom", ExDb: "^(template|postgres)"}
+
+	for _, dump := range dumps {
+
+		name := strings.TrimSuffix(filepath.Base(dump), ".sql")
+		content := readCorpusDump(t, filepath.Base(dump))
+		golden := readTestTree(t, filepath.Join("testdata", "golden", name, "custom"))
+
+		// identifiers of the objects and files they're stored in, the first object of the identifier is shown
+		paths := make(map[string]string)
+		var idents []string
+
+		err := VisitStream(&args, testScanner(content), Visitor{Object: func(dbo *DbObject) error {
+
+			obj := *dbo
+			obj.Content = strings.Builder{}
+			obj.Content.WriteString(dbo.Content.String())
+			obj.Paths = DbObjPath{IsCustom: true}
+
+			obj.normalizeDbObject()
+			obj.generateDestinationPath()
+
+			if obj.ObjSubtype != "" || obj.Paths.FullPath == "" {
+				return nil
+			}
+
+			ident := quoteTestIdent(obj.Name)
+			if fname, fargs := getFuncIdentParts(obj.Name); fname != "" {
+				ident = quoteTestIdent(fname) + "(" + fargs + ")"
+			}
+			if obj.Schema != "-" {
+				ident = quoteTestIdent(obj.Schema) + "." + ident
+			}
+
+			if _, found := paths[ident]; !found {
+				paths[ident] = filepath.ToSlash(obj.Paths.FullPath)
+				idents = append(idents, ident)
+			}
+
+			return nil
+		}})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(idents) == 0 {
+			t.Errorf("%s: no objects found", name)
+		}
+
+		for _, ident := range idents {
+
+			shown, err := showTestDump(t, args, content, ident)
+			if err != nil {
+				t.Errorf("%s: %s: %v", name, ident, err)
+				continue
+			}
+
+			if expected, found := golden[paths[ident]]; !found || shown != expected {
+				t.Errorf("%s: %s: shown content differs from %s:\n%s\nexpected:\n%s", name, ident, paths[ident], shown, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package dbobject
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Statistics of the run, collected while the dump is processed
+type RunStats struct {
+	Databases        []string       `json:"databases"`
+	SkippedDatabases []string       `json:"skipped_databases"`
+	ObjectsByType    map[string]int `json:"objects_by_type"`
+	ObjectsBySchema  map[string]int `json:"objects_by_schema"`
+	Excluded         map[string]int `json:"excluded"`
+	Unclassified     map[string]int `json:"unclassified"`
+	Objects          int            `json:"objects"`
+	FilesCreated     int            `json:"files_created"`
+	FilesAppended    int            `json:"files_appended"`
+	BytesWritten     int64          `json:"bytes_written"`
+	LinesRead        int            `json:"lines_read"`
+	ElapsedSeconds   float64        `json:"elapsed_seconds"`
+
+	started time.Time
+	dbs     map[string]bool
+	files   map[string]bool
+}
+
+var stats *RunStats
+
+func initStats() {
+
+	stats = &RunStats{
+		Databases:        []string{},
+		SkippedDatabases: []string{},
+		ObjectsByType:    make(map[string]int),
+		ObjectsBySchema:  make(map[string]int),
+		Excluded:         make(map[string]int),
+		Unclassified:     make(map[string]int),
+		started:          time.Now(),
+		dbs:              make(map[string]bool),
+		files:            make(map[string]bool),
+	}
+}
+
+// Records a database found in the dump and the decision whether it's processed
+func (st *RunStats) addDatabase(dbname string, processed bool) {
+
+	if st == nil || st.dbs[dbname] {
+		return
+	}
+
+	st.dbs[dbname] = true
+
+	if processed {
+		st.Databases = append(st.Databases, dbname)
+	} else {
+		st.SkippedDatabases = append(st.SkippedDatabases, dbname)
+	}
+}
+
+// Records an object passed to be stored
+func (st *RunStats) addObject(dbo *DbObject) {
+
+	if st == nil {
+		return
+	}
+
+	st.Objects++
+	st.ObjectsByType[dbo.ObjType]++
+
+	schema := dbo.Schema
+	if dbname := dbo.ownDatabase(); dbname != "" {
+		schema = dbname + "." + schema
+	}
+
+	st.ObjectsBySchema[schema]++
+}
+
+// Records an object skipped by the filter of given name
+func (st *RunStats) addExcluded(dbo *DbObject, filter string) {
+
+	if st == nil {
+		return
+	}
+
+	st.Excluded[filter]++
+}
+
+// Records content, which couldn't be stored into a file of an object, by the reason
+func (st *RunStats) addUnclassified(reason string) {
+
+	if st == nil {
+		return
+	}
+
+	st.Unclassified[reason]++
+}
+
+// Records data written to the file.
+// Files are counted once, as created or as appended to (when they existed before the run)
+func (st *RunStats) addWrite(path string, created bool, bytes int) {
+
+	if st == nil {
+		return
+	}
+
+	st.BytesWritten += int64(bytes)
+
+	if st.files[path] {
+		return
+	}
+
+	st.files[path] = true
+
+	if created {
+		st.FilesCreated++
+	} else {
+		st.FilesAppended++
+	}
+}
+
+// Prints statistics as a table or as a json document
+func (st *RunStats) Print(w io.Writer, format string) error {
+
+	elapsed := time.Since(st.started)
+	st.ElapsedSeconds = elapsed.Seconds()
+
+	if format == "json" {
+		data, err := json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Databases processed:\t%d\t%v\n", len(st.Databases), st.Databases)
+	fmt.Fprintf(tw, "Databases skipped:\t%d\t%v\n", len(st.SkippedDatabases), st.SkippedDatabases)
+	fmt.Fprintf(tw, "Lines read:\t%d\n", st.LinesRead)
+	fmt.Fprintf(tw, "Objects stored:\t%d\n", st.Objects)
+	fmt.Fprintf(tw, "Files created:\t%d\n", st.FilesCreated)
+	fmt.Fprintf(tw, "Files appended:\t%d\n", st.FilesAppended)
+	fmt.Fprintf(tw, "Bytes written:\t%d\n", st.BytesWritten)
+	fmt.Fprintf(tw, "Elapsed:\t%s\n", elapsed.Round(time.Millisecond))
+
+	printStatsMap(tw, "Objects per type:", st.ObjectsByType)
+	printStatsMap(tw, "Objects per schema:", st.ObjectsBySchema)
+	printStatsMap(tw, "Excluded objects per filter:", st.Excluded)
+	printStatsMap(tw, "Unclassified content per reason:", st.Unclassified)
+
+	return tw.Flush()
+}
+
+func printStatsMap(w io.Writer, title string, values map[string]int) {
+
+	if len(values) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(w, "\n%s\n", title)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s\t%d\n", k, values[k])
+	}
+}
+
+// Writes statistics in json format to the file. `-` stands for stdout
+func (st *RunStats) WriteJson(path string) error {
+
+	if path == "-" {
+		return st.Print(os.Stdout, "json")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return st.Print(f, "json")
+}
+
+// Follows renaming of the path written before, so the files below it are still counted once
+func (st *RunStats) movePath(from string, to string) {
+
+	if st == nil {
+		return
+	}
+
+	for path := range st.files {
+		if moved, ok := movedPath(path, from, to); ok {
+			delete(st.files, path)
+			st.files[moved] = true
+		}
+	}
+}
@@ -0,0 +1,519 @@
+package dbobject
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"pgdump_splitter/output"
+	"strings"
+	"testing"
+)
+
+// Part of the plain dump created by pg_dump 16 with --create option
+const testDumpCreate = `--
+-- PostgreSQL database dump
+--
+
+-- Dumped from database version 16.2 (Debian 16.2-1.pgdg120+2)
+-- Dumped by pg_dump version 16.3
+
+-- Started on 2024-05-02 10:15:01 CEST
+
+SET statement_timeout = 0;
+SET lock_timeout = 0;
+SET idle_in_transaction_session_timeout = 0;
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+SELECT pg_catalog.set_config('search_path', '', false);
+SET check_function_bodies = false;
+SET xmloption = content;
+SET client_min_messages = warning;
+SET row_security = off;
+
+--
+-- Name: shop; Type: DATABASE; Schema: -; Owner: app
+--
+
+CREATE DATABASE shop WITH TEMPLATE = template0 ENCODING = 'UTF8' LOCALE_PROVIDER = libc LOCALE = 'en_US.UTF-8';
+
+
+ALTER DATABASE shop OWNER TO app;
+
+\connect shop
+
+SET statement_timeout = 0;
+SET lock_timeout = 0;
+SET idle_in_transaction_session_timeout = 0;
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+SELECT pg_catalog.set_config('search_path', '', false);
+SET check_function_bodies = false;
+SET xmloption = content;
+SET client_min_messages = warning;
+SET row_security = off;
+
+--
+-- Name: billing; Type: SCHEMA; Schema: -; Owner: app
+--
+
+CREATE SCHEMA billing;
+
+
+ALTER SCHEMA billing OWNER TO app;
+
+SET default_tablespace = '';
+
+SET default_table_access_method = heap;
+
+--
+-- Name: invoice; Type: TABLE; Schema: billing; Owner: app
+--
+
+CREATE TABLE billing.invoice (
+    id integer NOT NULL,
+    total numeric(12,2)
+);
+
+
+ALTER TABLE billing.invoice OWNER TO app;
+
+--
+-- Name: invoice invoice_pkey; Type: CONSTRAINT; Schema: billing; Owner: app
+--
+
+ALTER TABLE ONLY billing.invoice
+    ADD CONSTRAINT invoice_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: TABLE invoice; Type: ACL; Schema: billing; Owner: app
+--
+
+GRANT SELECT ON TABLE billing.invoice TO app_ro;
+
+
+-- Completed on 2024-05-02 10:15:02 CEST
+
+--
+-- PostgreSQL database dump complete
+--
+
+`
+
+// Splits the dump given as a string into a temporary directory and returns its location
+func splitTestDump(t *testing.T, args Config, dump string) string {
+
+	t.Helper()
+
+	if args.Dest == "" {
+		args.Dest = t.TempDir()
+	}
+
+	if args.BufS == 0 {
+		args.BufS = 1024 * 1024
+	}
+
+	if args.Progress == "" {
+		args.Progress = "off"
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(dump))
+	scanner.Split(preserveNewlines)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), args.BufS)
+
+	if err := IsExclObjTypeOk(args.ExOT); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ProcessStream(&args, scanner); err != nil {
+		t.Fatal(err)
+	}
+
+	return args.Dest
+}
+
+// Reads all files found in the directory. Keys of the result are paths relative to the directory
+func readTestTree(t *testing.T, dir string) map[string]string {
+
+	t.Helper()
+
+	tree := make(map[string]string)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(dir, path)
+		tree[filepath.ToSlash(rel)] = string(content)
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tree
+}
+
+func TestStreamFileHeader(t *testing.T) {
+
+	dir := splitTestDump(t, Config{Mode: "custom", Header: "full", Version: "1.2.1"}, testDumpCreate)
+	tree := readTestTree(t, dir)
+
+	want := "--\n" +
+		"-- Source database: shop\n" +
+		"-- Dumped from database version 16.2 (Debian 16.2-1.pgdg120+2)\n" +
+		"-- Dumped by pg_dump version 16.3\n" +
+		"-- Started on 2024-05-02 10:15:01 CEST\n" +
+		"-- Split by pgdump_splitter 1.2.1\n" +
+		"--\n\n" +
+		"CREATE TABLE billing.invoice ("
+
+	if got := tree["shop/billing/table/invoice.sql"]; !strings.HasPrefix(got, want) {
+		t.Errorf("got %q, wants prefix %q", got, want)
+	}
+
+	if got := tree["shop/-/database/shop.sql"]; !strings.HasPrefix(got, "--\n-- Source database: shop\n") {
+		t.Errorf("got %q, expected header of database shop", got)
+	}
+
+	dir = splitTestDump(t, Config{Mode: "custom", Header: "stable", Version: "1.2.1"}, testDumpCreate)
+	tree = readTestTree(t, dir)
+
+	want = "--\n-- Source database: shop\n-- Dumped from database version 16\n--\n\nCREATE TABLE billing.invoice ("
+
+	if got := tree["shop/billing/table/invoice.sql"]; !strings.HasPrefix(got, want) {
+		t.Errorf("got %q, wants prefix %q", got, want)
+	}
+
+	dir = splitTestDump(t, Config{Mode: "custom"}, testDumpCreate)
+	tree = readTestTree(t, dir)
+
+	if got := tree["shop/billing/table/invoice.sql"]; !strings.HasPrefix(got, "CREATE TABLE billing.invoice (") {
+		t.Errorf("got %q, expected no header", got)
+	}
+}
+
+func TestMajorVersion(t *testing.T) {
+
+	cases := map[string]string{
+		"16.2 (Debian 16.2-1.pgdg120+2)": "16",
+		"9.6.24":                         "9.6",
+		"18beta1":                        "18beta1",
+	}
+
+	for src, want := range cases {
+		if got := majorVersion(src); want != got {
+			t.Errorf("got %s, wants %s", got, want)
+		}
+	}
+}
+
+func TestSupportsRestrict(t *testing.T) {
+
+	for _, tc := range []struct {
+		version string
+		want    bool
+	}{
+		{"", true},
+		{"16.2 (Debian 16.2-1.pgdg120+2)", false},
+		{"16.10 (Debian 16.10-1.pgdg120+1)", true},
+		{"17.5", false},
+		{"17.6", true},
+		{"17beta3", false},
+		{"18.0", true},
+		{"18rc1", true},
+		{"18beta3", true},
+		{"19devel", true},
+		{"25.1", true},
+		{"12.22", false},
+		{"9.6.24", false},
+		{"devel", true},
+		{"unknown", true},
+	} {
+		if got := (&DumpInfo{PgDumpVersion: tc.version}).supportsRestrict(); got != tc.want {
+			t.Errorf("%q: got %t, wants %t", tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestStreamDumpInfo(t *testing.T) {
+
+	dir := splitTestDump(t, Config{Mode: "custom", DumpInfo: true}, testDumpCreate)
+
+	data, err := os.ReadFile(filepath.Join(dir, "shop", dumpInfoFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info DumpInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Database != "shop" || info.ServerVersion != "16.2 (Debian 16.2-1.pgdg120+2)" || info.PgDumpVersion != "16.3" ||
+		info.StartedOn != "2024-05-02 10:15:01 CEST" || info.ClientEncoding != "UTF8" || info.StandardConformingStrings != "on" ||
+		info.SearchPath == nil || *info.SearchPath != "" {
+		t.Errorf("unexpected dump info: %s", string(data))
+	}
+
+	// metadata change with every dump, so they are written only if requested
+	if _, found := readTestTree(t, splitTestDump(t, Config{Mode: "custom"}, testDumpCreate))["shop/"+dumpInfoFileName]; found {
+		t.Errorf("dump info not expected by default")
+	}
+}
+
+func TestStreamRestrictByVersion(t *testing.T) {
+
+	body := "--\n-- Name: f(); Type: FUNCTION; Schema: public; Owner: app\n--\n\nCREATE FUNCTION public.f() RETURNS text\n    LANGUAGE sql\n    AS $$\n\\restrict abc\n$$;\n"
+
+	dump := "--\n-- PostgreSQL database dump\n--\n\n\\restrict abc\n\n-- Dumped from database version 16.2\n-- Dumped by pg_dump version 16.2\n\n" + body
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom"}, dump))
+
+	if got := tree["public/function/f.sql"]; !strings.Contains(got, "\\restrict abc") {
+		t.Errorf("got %q, expected restrict line kept for pg_dump 16.2", got)
+	}
+
+	dump = "--\n-- PostgreSQL database dump\n--\n\n\\restrict abc\n\n-- Dumped from database version 16.10\n-- Dumped by pg_dump version 16.10\n\n" + body
+	tree = readTestTree(t, splitTestDump(t, Config{Mode: "custom"}, dump))
+
+	if got := tree["public/function/f.sql"]; strings.Contains(got, "\\restrict abc") {
+		t.Errorf("got %q, expected restrict line skipped for pg_dump 16.10", got)
+	}
+}
+
+func TestStreamPrologue(t *testing.T) {
+
+	prologue := "SET statement_timeout = 0;\n" +
+		"SET lock_timeout = 0;\n" +
+		"SET idle_in_transaction_session_timeout = 0;\n" +
+		"SET client_encoding = 'UTF8';\n" +
+		"SET standard_conforming_strings = on;\n" +
+		"SELECT pg_catalog.set_config('search_path', '', false);\n" +
+		"SET check_function_bodies = false;\n" +
+		"SET xmloption = content;\n" +
+		"SET client_min_messages = warning;\n" +
+		"SET row_security = off;\n"
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", Prologue: "file"}, testDumpCreate))
+
+	if got := tree["shop/"+prologueFileName]; prologue != got {
+		t.Errorf("got %q, wants %q", got, prologue)
+	}
+
+	if got := tree["shop/billing/table/invoice.sql"]; !strings.HasPrefix(got, "CREATE TABLE") {
+		t.Errorf("got %q, expected no prologue prepended", got)
+	}
+
+	tree = readTestTree(t, splitTestDump(t, Config{Mode: "custom", Prologue: "prepend"}, testDumpCreate))
+
+	if got := tree["shop/billing/table/invoice.sql"]; !strings.HasPrefix(got, prologue+"\nCREATE TABLE billing.invoice (") {
+		t.Errorf("got %q, expected prologue prepended", got)
+	}
+
+	for _, mode := range []string{"none", ""} {
+
+		tree = readTestTree(t, splitTestDump(t, Config{Mode: "custom", Prologue: mode}, testDumpCreate))
+
+		if _, found := tree["shop/"+prologueFileName]; found {
+			t.Errorf("%q: prologue file not expected", mode)
+		}
+	}
+}
+
+func TestStreamDryRun(t *testing.T) {
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "shop", "billing", "table", "invoice.sql")
+
+	if err := os.MkdirAll(filepath.Dir(existing), 0770); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(existing, []byte("-- old\n"), 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	splitTestDump(t, Config{Mode: "custom", Dest: dir, DryRun: true, DumpInfo: true}, testDumpCreate)
+
+	tree := readTestTree(t, dir)
+	if len(tree) != 1 || tree["shop/billing/table/invoice.sql"] != "-- old\n" {
+		t.Errorf("dry run is not expected to modify the destination: %v", tree)
+	}
+
+	planned := make(map[string]*PlannedFile)
+	for _, pf := range dryrun.Files {
+		rel, _ := filepath.Rel(dir, pf.Path)
+		planned[filepath.ToSlash(rel)] = pf
+	}
+
+	if pf := planned["shop/billing/table/invoice.sql"]; pf == nil || pf.Action != "append" || strings.Join(pf.ObjectTypes, ",") != "TABLE,CONSTRAINT,ACL" {
+		t.Errorf("unexpected plan for the table: %+v", pf)
+	}
+
+	if pf := planned["shop/billing/billing.sql"]; pf == nil || pf.Action != "create" {
+		t.Errorf("unexpected plan for the schema: %+v", pf)
+	}
+
+	if pf := planned["shop/"+dumpInfoFileName]; pf == nil || pf.Action != "write" {
+		t.Errorf("unexpected plan for the dump info: %+v", pf)
+	}
+
+	splitTestDump(t, Config{Mode: "custom", Dest: dir, DryRun: true, Cln: true}, testDumpCreate)
+
+	if len(dryrun.Remove) != 1 || dryrun.Remove[0] != existing {
+		t.Errorf("unexpected files to remove: %v", dryrun.Remove)
+	}
+
+	for _, pf := range dryrun.Files {
+		if pf.Action == "append" {
+			t.Errorf("no file expected to be appended after cleaning: %s", pf.Path)
+		}
+	}
+}
+
+func TestStreamStats(t *testing.T) {
+
+	splitTestDump(t, Config{Mode: "custom", ExOT: "^CONSTRAINT$", Normalize: "privileges"}, testDumpCreate)
+
+	if stats.Objects != 3 || stats.ObjectsByType["TABLE"] != 1 || stats.ObjectsBySchema["shop.billing"] != 1 {
+		t.Errorf("unexpected object counts: %+v", stats)
+	}
+
+	if stats.Excluded["exclude-objects"] != 1 || stats.Excluded["normalize"] != 1 {
+		t.Errorf("unexpected excluded counts: %v", stats.Excluded)
+	}
+
+	if len(stats.Databases) != 1 || stats.Databases[0] != "shop" {
+		t.Errorf("unexpected databases: %v", stats.Databases)
+	}
+
+	// 3 objects
+	if stats.FilesCreated != 3 || stats.FilesAppended != 0 || stats.BytesWritten == 0 {
+		t.Errorf("unexpected file counts: %+v", stats)
+	}
+
+	if stats.LinesRead != strings.Count(testDumpCreate, "\n") {
+		t.Errorf("got %d lines, wants %d", stats.LinesRead, strings.Count(testDumpCreate, "\n"))
+	}
+}
+
+func TestStreamStatsDatabases(t *testing.T) {
+
+	// pg_dumpall connects to postgres itself, other databases are created by pg_dump --create before they're connected
+	postgres := `--
+-- Database "postgres" dump
+--
+
+\connect postgres
+
+--
+-- PostgreSQL database dump
+--
+
+SET statement_timeout = 0;
+
+--
+-- PostgreSQL database dump complete
+--
+
+`
+
+	for mode, want := range map[string]string{"custom": "shop/-/database/shop.sql", "origin": "shop/-/DATABASE/shop.sql"} {
+
+		tree := readTestTree(t, splitTestDump(t, Config{Mode: mode, ExDb: "^postgres$"}, postgres+testDumpCreate))
+
+		// the DATABASE object is processed and counted according to its own name, not the skipped database connected before
+		if content := tree[want]; !strings.HasPrefix(content, "CREATE DATABASE shop ") {
+			t.Errorf("%s: expected the DATABASE object in %s, got: %v", mode, want, tree)
+		}
+
+		for path := range tree {
+			if !strings.HasPrefix(path, "shop/") {
+				t.Errorf("%s: unexpected file %s", mode, path)
+			}
+		}
+
+		if stats.ObjectsBySchema["shop.-"] != 2 || stats.ObjectsBySchema["postgres.-"] != 0 || stats.Excluded["database"] != 0 {
+			t.Errorf("%s: unexpected counts: %v, excluded: %v", mode, stats.ObjectsBySchema, stats.Excluded)
+		}
+	}
+
+	// objects of skipped databases are counted as excluded, including the DATABASE object
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", ExDb: "^shop$"}, postgres+testDumpCreate))
+
+	if want := strings.Count(testDumpCreate, "\n-- Name: "); stats.Excluded["database"] != want || stats.Objects != 0 {
+		t.Errorf("expected %d objects excluded, got: %v, %d stored", want, stats.Excluded, stats.Objects)
+	}
+
+	for path := range tree {
+		if strings.HasPrefix(path, "shop/") {
+			t.Errorf("unexpected file %s", path)
+		}
+	}
+
+	if len(stats.SkippedDatabases) != 1 || stats.SkippedDatabases[0] != "shop" {
+		t.Errorf("unexpected skipped databases: %v", stats.SkippedDatabases)
+	}
+}
+
+func TestProgressLog(t *testing.T) {
+
+	var sb strings.Builder
+
+	pr := &progressReporter{w: &sb, logmode: true, total: 200}
+	line := strings.Repeat("x", 49) + "\n"
+
+	for i := 0; i < 2; i++ {
+		pr.tick(&line, "shop")
+	}
+	pr.finish("shop")
+
+	want := `progress bytes=100 total=200 percent=50.0 lines=2 database="shop" objects=`
+	if got := sb.String(); !strings.HasPrefix(got, want) || !strings.HasSuffix(got, "final=true\n") {
+		t.Errorf("got %q, wants prefix %q", got, want)
+	}
+
+	if got := formatBytes(20 * 1024 * 1024 * 1024); got != "20.0 GiB" {
+		t.Errorf("got %s, wants 20.0 GiB", got)
+	}
+}
+
+func TestStreamDebugTrace(t *testing.T) {
+
+	var sb strings.Builder
+
+	defaultLog := output.Log
+	defer func() { output.Log = defaultLog }()
+
+	if err := output.SetupLog(&sb, "debug", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	splitTestDump(t, Config{Mode: "custom", ExOT: "^ACL$"}, testDumpCreate)
+
+	trace := sb.String()
+
+	for _, want := range []string{
+		`"msg":"object header","line":69,"type":"CONSTRAINT","name":"invoice invoice_pkey"`,
+		`"msg":"normalize object","line":69,"type":"CONSTRAINT","name":"invoice_pkey","schema":"billing","subtype":"TABLE","subname":"invoice"`,
+		`"msg":"exclude object","line":77,"type":"ACL","name":"TABLE invoice","database":"shop","filter":"exclude-objects"`,
+		`"msg":"store object","line":57,"type":"TABLE","name":"invoice","path":`,
+	} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("trace does not contain %s", want)
+		}
+	}
+
+	if err := output.SetupLog(&sb, "verbose", "json"); err == nil {
+		t.Errorf("expected error for invalid log level")
+	}
+}
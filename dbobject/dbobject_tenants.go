@@ -0,0 +1,163 @@
+package dbobject
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"pgdump_splitter/output"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Placeholder replacing the schema name, when contents of schemas are compared
+const tenantPlaceholder = "{{schema}}"
+
+// Collapses schemas of identical content (ie one schema per tenant) into a single reference schema
+type tenantCollapser struct {
+	rgx_schemas *regexp.Regexp
+	reference   string
+}
+
+// Collapser of the current run. Nil if all schemas are stored
+var tenants *tenantCollapser
+
+func initTenants(args *Config) error {
+
+	tenants = nil
+
+	if args.TenantSchemas == "" {
+		if args.TenantReference != "" {
+			return fmt.Errorf("reference tenant schema requires -tenant-schemas to be set")
+		}
+		return nil
+	}
+
+	rgx, err := regexp.Compile(args.TenantSchemas)
+	if err != nil {
+		return fmt.Errorf("invalid regular expression for tenant schemas")
+	}
+
+	if args.TenantReference != "" && !rgx.MatchString(args.TenantReference) {
+		return fmt.Errorf("reference tenant schema %s doesn't match -tenant-schemas", args.TenantReference)
+	}
+
+	tenants = &tenantCollapser{rgx_schemas: rgx, reference: args.TenantReference}
+
+	return nil
+}
+
+// Returns the schema the normalized object belongs to
+func tenantSchemaOf(dbo *DbObject) string {
+
+	if dbo.ObjType == "SCHEMA" {
+		return dbo.Name
+	}
+
+	return dbo.Schema
+}
+
+// Calculates fingerprint of the schema content with the name of the schema replaced by a placeholder
+func tenantFingerprint(schema string, objs []*DbObject) string {
+
+	rgx := regexp.MustCompile(`\b` + regexp.QuoteMeta(schema) + `\b`)
+	hash := sha256.New()
+
+	for _, dbo := range objs {
+		// content is trimmed the same way as it's stored
+		for _, s := range []string{dbo.ObjType, dbo.ObjSubtype, dbo.ObjSubName, dbo.Name, strings.Trim(dbo.Content.String(), " -\n")} {
+			hash.Write([]byte(rgx.ReplaceAllLiteralString(s, tenantPlaceholder)))
+			hash.Write([]byte{0})
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Drops objects of schemas identical to the reference schema and writes the list of such schemas
+// to `_members.txt` in the directory of the reference schema. Returns objects to be stored.
+func (tc *tenantCollapser) collapse(objs []*DbObject) ([]*DbObject, error) {
+
+	if tc == nil {
+		return objs, nil
+	}
+
+	// objects of candidate schemas, in order of appearance
+	var schemas []string
+	content := make(map[string][]*DbObject)
+
+	for _, dbo := range objs {
+
+		schema := tenantSchemaOf(dbo)
+
+		if !tc.rgx_schemas.MatchString(schema) {
+			continue
+		}
+
+		if _, found := content[schema]; !found {
+			schemas = append(schemas, schema)
+		}
+
+		content[schema] = append(content[schema], dbo)
+	}
+
+	if len(schemas) < 2 {
+		return objs, nil
+	}
+
+	reference := schemas[0]
+
+	if tc.reference != "" {
+		if _, found := content[tc.reference]; found {
+			reference = tc.reference
+		} else {
+			output.Log.Warn("reference tenant schema not found, using the first matching one", "reference", tc.reference, "schema", reference)
+		}
+	}
+
+	fingerprint := tenantFingerprint(reference, content[reference])
+	members := []string{reference}
+	collapsed := make(map[string]bool)
+
+	for _, schema := range schemas {
+
+		if schema == reference {
+			continue
+		}
+
+		if tenantFingerprint(schema, content[schema]) == fingerprint {
+			members = append(members, schema)
+			collapsed[schema] = true
+		} else {
+			output.Log.Info("tenant schema deviates from the reference", "schema", schema, "reference", reference)
+		}
+	}
+
+	kept := make([]*DbObject, 0, len(objs))
+
+	for _, dbo := range objs {
+
+		if collapsed[tenantSchemaOf(dbo)] {
+			excludeObject(dbo, "tenant-schemas")
+			continue
+		}
+
+		kept = append(kept, dbo)
+	}
+
+	sort.Strings(members)
+
+	ref := content[reference][0]
+	var dbpath string
+	if ref.Database != "" && !ref.Paths.NoDbInPath {
+		dbpath = ref.Database
+	}
+
+	path, err := resolveOutputPath(ref.Paths.Rootpath, filepath.Join(ref.Paths.Rootpath, EscapePathComponent(dbpath), EscapePathComponent(reference), "_members.txt"))
+	if err != nil {
+		return kept, err
+	}
+
+	return kept, writeOutputFile(path, []byte(strings.Join(members, "\n")+"\n"), "TENANT MEMBERS")
+}
@@ -0,0 +1,95 @@
+package dbobject
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Returns sorted top level directories of the split tree
+func testTreeDirs(tree map[string]string) []string {
+
+	var dirs []string
+	seen := make(map[string]bool)
+
+	for path := range tree {
+		if dir, _, found := strings.Cut(path, "/"); found && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	sort.Strings(dirs)
+
+	return dirs
+}
+
+// Splits the tenants database of the testdata corpus: tenant_0010 has an extra column, tenant_0012 lacks an index
+func TestTenantSchemas(t *testing.T) {
+
+	dump := readCorpusDump(t, "pg_dump_tenants_16.sql")
+
+	for _, mode := range []string{"custom", "origin"} {
+
+		tree := readTestTree(t, splitTestDump(t, Config{Mode: mode, TenantSchemas: `^tenant_\d+$`}, dump))
+
+		if members := tree["tenant_0001/_members.txt"]; members != "tenant_0001\ntenant_0002\ntenant_0011\n" {
+			t.Errorf("%s: unexpected members of the reference schema: %q", mode, members)
+		}
+
+		// schemas not matching the expression are stored as they are, deviating tenant schemas next to the reference one
+		if dirs, want := testTreeDirs(tree), []string{"shared", "tenant_0001", "tenant_0010", "tenant_0012"}; !reflect.DeepEqual(dirs, want) {
+			t.Errorf("%s: got schemas %v, wants %v", mode, dirs, want)
+		}
+
+		for _, path := range []string{"tenant_0010/_members.txt", "tenant_0012/_members.txt"} {
+			if _, found := tree[path]; found {
+				t.Errorf("%s: deviating tenant schema should not have members, got: %s", mode, path)
+			}
+		}
+	}
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", TenantSchemas: `^tenant_\d+$`}, dump))
+
+	if table := tree["tenant_0010/table/orders.sql"]; !strings.Contains(table, "    note text\n") {
+		t.Errorf("expected the extra column in the deviating schema, got: %q", table)
+	}
+
+	if table := tree["tenant_0001/table/orders.sql"]; !strings.Contains(table, "CREATE INDEX orders_customer_idx ON tenant_0001.orders") {
+		t.Errorf("expected the index in the reference schema, got: %q", table)
+	}
+
+	if table := tree["tenant_0012/table/orders.sql"]; table == "" || strings.Contains(table, "CREATE INDEX") {
+		t.Errorf("expected the table without the index in the deviating schema, got: %q", table)
+	}
+}
+
+func TestTenantReference(t *testing.T) {
+
+	dump := readCorpusDump(t, "pg_dump_tenants_16.sql")
+
+	// objects of the collapsed schemas, including their ACLs written by pg_dump with schema `-`
+	var want int
+	for _, schema := range []string{"tenant_0001", "tenant_0011"} {
+		want += strings.Count(dump, "; Schema: "+schema+"; ") + strings.Count(dump, "Name: SCHEMA "+schema+"; ") + strings.Count(dump, "Name: "+schema+"; Type: SCHEMA;")
+	}
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", TenantSchemas: `^tenant_\d+$`, TenantReference: "tenant_0002"}, dump))
+
+	if members := tree["tenant_0002/_members.txt"]; members != "tenant_0001\ntenant_0002\ntenant_0011\n" {
+		t.Errorf("unexpected members of the reference schema: %q", members)
+	}
+
+	if dirs, want := testTreeDirs(tree), []string{"shared", "tenant_0002", "tenant_0010", "tenant_0012"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("got schemas %v, wants %v", dirs, want)
+	}
+
+	if excluded := stats.Excluded["tenant-schemas"]; excluded != want {
+		t.Errorf("expected %d objects of the member schemas excluded, got: %d", want, excluded)
+	}
+
+	if err := initTenants(&Config{TenantSchemas: `^tenant_`, TenantReference: "public"}); err == nil {
+		t.Errorf("expected error for reference schema not matching the expression")
+	}
+}
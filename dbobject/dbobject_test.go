@@ -228,7 +228,7 @@ func TestForeignDataWrapperAcl(t *testing.T) {
 		Paths: DbObjPath{
 			Rootpath:    "/root/",
 			NameForFile: "dblink_fdw",
-			FullPath:    "/root/-/foreign data wrapper/dblink_fdw.sql",
+			FullPath:    "/root/_foreign/foreign data wrapper/dblink_fdw.sql",
 			IsCustom:    true,
 		},
 	}
@@ -263,3 +263,34 @@ func TestDatabaseAclPath(t *testing.T) {
 		t.Errorf("test TestDatabaseAclPath() failed")
 	}
 }
+
+func TestQuotedNamesPath(t *testing.T) {
+
+	for _, tc := range []struct {
+		objtype string
+		name    string
+		content string
+		want    string
+	}{
+		{"ACL", `TABLE "Order Lines"`, `GRANT SELECT ON TABLE "Sales"."Order Lines" TO app_ro;`, "/root/Sales/table/Order Lines.sql"},
+		{"COMMENT", `COLUMN "Order Lines"."Line No"`, `COMMENT ON COLUMN "Sales"."Order Lines"."Line No" IS 'position';`, "/root/Sales/table/Order Lines.sql"},
+		{"COMMENT", `POLICY "Tenant" ON "Order Lines"`, `COMMENT ON POLICY "Tenant" ON "Sales"."Order Lines" IS 'tenant';`, "/root/Sales/table/Order Lines.sql"},
+		{"CONSTRAINT", "Order Lines Order Lines_pkey", "ALTER TABLE ONLY \"Sales\".\"Order Lines\"\n    ADD CONSTRAINT \"Order Lines_pkey\" PRIMARY KEY (id);", "/root/Sales/table/Order Lines.sql"},
+		{"FK CONSTRAINT", "Order Lines Order Lines_invoice_fkey", "ALTER TABLE ONLY \"Sales\".\"Order Lines\"\n    ADD CONSTRAINT \"Order Lines_invoice_fkey\" FOREIGN KEY (invoice) REFERENCES \"Sales\".invoice(id);", "/root/Sales/table/Order Lines.sql"},
+		{"TRIGGER", "Order Lines audit", `CREATE TRIGGER audit BEFORE UPDATE ON "Sales"."Order Lines" FOR EACH ROW EXECUTE FUNCTION "Sales".audit();`, "/root/Sales/table/Order Lines.sql"},
+		{"INDEX", "Order Lines_amount_idx", `CREATE INDEX "Order Lines_amount_idx" ON "Sales"."Order Lines" USING btree (amount);`, "/root/Sales/table/Order Lines.sql"},
+		// without the relation in the content, the name is split at the last space
+		{"CONSTRAINT", "Order Lines Order Lines_pkey", "", "/root/Sales/table/Order Lines Order.sql"},
+	} {
+
+		dbo := DbObject{Name: tc.name, ObjType: tc.objtype, Schema: "Sales", Paths: DbObjPath{Rootpath: "/root/", IsCustom: true}}
+		dbo.Content.WriteString(tc.content)
+
+		dbo.normalizeDbObject()
+		dbo.generateDestinationPath()
+
+		if dbo.Paths.FullPath != tc.want {
+			t.Errorf("%s %s: got %s, wants %s", tc.objtype, tc.name, dbo.Paths.FullPath, tc.want)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package dbobject
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// identifier, which doesn't have to be quoted (ie name of a database in `\connect`)
+var rgx_plainIdent *regexp.Regexp
+
+func init() {
+
+	rgx_plainIdent = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+	joinTypeRanks = make(map[string]int, len(joinTypeOrder))
+
+	for i, objtype := range joinTypeOrder {
+		joinTypeRanks[objtype] = i + 1
+	}
+}
+
+// Lists files of the structure created by the splitter, as slash separated paths relative to the directory, sorted
+func listTreeFiles(dir string) ([]string, error) {
+
+	var files []string
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, filepath.ToSlash(rel))
+
+		return nil
+	})
+
+	sort.Strings(files)
+
+	return files, err
+}
+
+// Order in which files are joined by the type of their objects, so objects are created after the objects they depend on.
+// It follows the order of pg_dump. Files of other types follow, in order of their paths.
+// Files preceding the prologue of a database are joined before it's connected.
+var joinTypeOrder = []string{
+	"DROP", "ROLE", "ROLE SETTINGS", "TABLESPACE", "PARAMETER ACL", "DATABASE", prologueFileName, "DATABASE PROPERTIES",
+	"SCHEMA", "EXTENSION", "PROCEDURAL LANGUAGE", "COLLATION", "TRANSFORM", "SHELL TYPE", "TYPE", "DOMAIN", "CAST",
+	"FUNCTION", "PROCEDURE", "AGGREGATE", "ACCESS METHOD", "OPERATOR", "OPERATOR FAMILY", "OPERATOR CLASS", "CONVERSION",
+	"TEXT SEARCH PARSER", "TEXT SEARCH TEMPLATE", "TEXT SEARCH DICTIONARY", "TEXT SEARCH CONFIGURATION",
+	"FOREIGN DATA WRAPPER", "SERVER", "USER MAPPING",
+	"SEQUENCE", "TABLE", "FOREIGN TABLE", "VIEW", "MATERIALIZED VIEW", "TABLE ATTACH", "DEFAULT", "SEQUENCE OWNED BY",
+	"TABLE DATA", "SEQUENCE SET", "LARGE OBJECT", "LARGE OBJECTS", "BLOB", "BLOBS",
+	"CONSTRAINT", "CHECK CONSTRAINT", "INDEX", "INDEX ATTACH", "STATISTICS", "STATISTICS DATA", "RULE", "TRIGGER",
+	"FK CONSTRAINT", "POLICY", "ROW SECURITY", "PUBLICATION", "PUBLICATION TABLE", "PUBLICATION TABLES IN SCHEMA",
+	"SUBSCRIPTION", "SUBSCRIPTION TABLE", "DEFAULT ACL", "EVENT TRIGGER", "MATERIALIZED VIEW DATA",
+	"COMMENT", "SECURITY LABEL", "ACL",
+}
+
+// rank of each type of joinTypeOrder, starting with 1
+var joinTypeRanks map[string]int
+
+// Returns rank of the file in the joined script, given its slash separated path relative to the directory of its database.
+// Unclassified content goes last.
+func joinRank(rel string) int {
+
+	parts := strings.Split(rel, "/")
+	file := parts[len(parts)-1]
+
+	var objtype string
+
+	switch {
+	case rel == prologueFileName:
+		objtype = prologueFileName
+	case rel == unclassifiedFileName:
+		return len(joinTypeOrder) + 2
+	case strings.HasSuffix(file, ".acl.sql"):
+		objtype = "ACL"
+	case len(parts) == 2 && parts[0] == databaseSectionPath("EXTENSION"):
+		objtype = "EXTENSION"
+	case len(parts) == 2 && file == parts[0]+".sql":
+		objtype = "SCHEMA"
+	case len(parts) > 2:
+		// directories are named by the type (in lower case in custom mode), spaces might be replaced by underscores
+		objtype = strings.ToUpper(strings.ReplaceAll(parts[len(parts)-2], "_", " "))
+	}
+
+	if rank, found := joinTypeRanks[objtype]; found {
+		return rank
+	}
+
+	return len(joinTypeOrder) + 1
+}
+
+// Sorts files of a database (paths relative to its directory) in order they are joined.
+// Files of the same rank are sorted by their paths, companions (ie invoice.partitions.sql) follow the file of their object.
+func sortJoinedFiles(files []string) {
+
+	sort.SliceStable(files, func(i, j int) bool {
+
+		ri, rj := joinRank(files[i]), joinRank(files[j])
+		if ri != rj {
+			return ri < rj
+		}
+
+		return strings.TrimSuffix(files[i], ".sql") < strings.TrimSuffix(files[j], ".sql")
+	})
+}
+
+// Tells whether the structure is created from output of pg_dumpall, so its top level directories (other than `-` of the cluster)
+// are directories of databases. Such structure holds roles of the cluster, or a directory of a database is recognized
+// by the prologue, metadata or objects belonging to no schema (ie the DATABASE object) in it.
+func isClusterTree(dir string) bool {
+
+	cluster, _ := os.ReadDir(filepath.Join(dir, "-"))
+
+	for _, e := range cluster {
+		if e.IsDir() && strings.EqualFold(e.Name(), generateObjTypePath("ROLE", false)) {
+			return true
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+
+	for _, e := range entries {
+
+		if !e.IsDir() || e.Name() == "-" {
+			continue
+		}
+
+		for _, name := range []string{prologueFileName, dumpInfoFileName, "-"} {
+			if _, err := os.Stat(filepath.Join(dir, e.Name(), name)); err == nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Returns the psql meta-command connecting the database of given name
+func connectCommand(dbname string) string {
+
+	if rgx_plainIdent.MatchString(dbname) {
+		return "\\connect " + dbname
+	}
+
+	quoted := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `"`, `""`).Replace(dbname)
+
+	return `\connect -reuse-previous=on "dbname='` + quoted + `'"`
+}
+
+// Concatenates sql files of the structure into a single script, each preceded by a comment with its path.
+// Metadata files (ie _dump_info.json) are skipped. Files of every database start with its prologue, followed by files
+// in order of types of their objects (schemas, extensions, types, functions, tables, ...), see joinTypeOrder.
+// In structures of pg_dumpall, cluster files go first, and files of each database are preceded by `\connect` to it.
+func JoinTree(dir string, w io.Writer) error {
+
+	files, err := listTreeFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	// files of the cluster (or of the only database), and files of each database, keyed by its directory
+	var cluster []string
+	databases := make(map[string][]string)
+	var dbdirs []string
+
+	dumpall := isClusterTree(dir)
+
+	for _, rel := range files {
+
+		if !strings.HasSuffix(rel, ".sql") {
+			continue
+		}
+
+		if top, inner, found := strings.Cut(rel, "/"); dumpall && found && top != "-" {
+
+			if _, known := databases[top]; !known {
+				dbdirs = append(dbdirs, top)
+			}
+
+			databases[top] = append(databases[top], inner)
+			continue
+		}
+
+		cluster = append(cluster, rel)
+	}
+
+	sortJoinedFiles(cluster)
+
+	if err := joinFiles(w, dir, "", cluster); err != nil {
+		return err
+	}
+
+	for _, dbdir := range dbdirs {
+
+		dbname, err := UnescapePathComponent(dbdir)
+		if err != nil {
+			return err
+		}
+
+		dbfiles := databases[dbdir]
+		sortJoinedFiles(dbfiles)
+
+		// the database (and roles it's owned by) is created before it's connected
+		created := 0
+		for created < len(dbfiles) && joinRank(dbfiles[created]) < joinTypeRanks[prologueFileName] {
+			created++
+		}
+
+		if err := joinFiles(w, dir, dbdir, dbfiles[:created]); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "%s\n\n", connectCommand(dbname)); err != nil {
+			return err
+		}
+
+		if err := joinFiles(w, dir, dbdir, dbfiles[created:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Writes the files, given by slash separated paths relative to the subdirectory of the structure, each preceded by a comment with its path
+func joinFiles(w io.Writer, dir string, subdir string, files []string) error {
+
+	for _, rel := range files {
+
+		rel = path.Join(subdir, rel)
+
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "--\n-- File: %s\n--\n\n%s\n", rel, bytes.TrimRight(content, "\n")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Differences between two structures
+type TreeDiff struct {
+	// files found only in the second structure
+	Added []string `json:"added"`
+	// files found only in the first structure
+	Removed []string `json:"removed"`
+	// files of different content
+	Changed []string `json:"changed"`
+}
+
+// Compares files of two structures
+func DiffTrees(a string, b string) (*TreeDiff, error) {
+
+	filesA, err := listTreeFiles(a)
+	if err != nil {
+		return nil, err
+	}
+
+	filesB, err := listTreeFiles(b)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &TreeDiff{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	inB := make(map[string]bool, len(filesB))
+
+	for _, rel := range filesB {
+		inB[rel] = true
+	}
+
+	inA := make(map[string]bool, len(filesA))
+
+	for _, rel := range filesA {
+
+		inA[rel] = true
+
+		if !inB[rel] {
+			diff.Removed = append(diff.Removed, rel)
+			continue
+		}
+
+		same, err := sameContent(filepath.Join(a, filepath.FromSlash(rel)), filepath.Join(b, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+
+		if !same {
+			diff.Changed = append(diff.Changed, rel)
+		}
+	}
+
+	for _, rel := range filesB {
+		if !inA[rel] {
+			diff.Added = append(diff.Added, rel)
+		}
+	}
+
+	return diff, nil
+}
+
+func sameContent(a string, b string) (bool, error) {
+
+	contentA, err := os.ReadFile(a)
+	if err != nil {
+		return false, err
+	}
+
+	contentB, err := os.ReadFile(b)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(contentA, contentB), nil
+}
+
+// Tells whether any difference was found
+func (diff *TreeDiff) Empty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
+
+// Prints differences either as lines prefixed by +, - and ~ (added, removed, changed) or as a json document
+func (diff *TreeDiff) Print(w io.Writer, format string) error {
+
+	if format == "json" {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	for _, rel := range diff.Removed {
+		fmt.Fprintf(w, "- %s\n", rel)
+	}
+
+	for _, rel := range diff.Added {
+		fmt.Fprintf(w, "+ %s\n", rel)
+	}
+
+	for _, rel := range diff.Changed {
+		fmt.Fprintf(w, "~ %s\n", rel)
+	}
+
+	return nil
+}
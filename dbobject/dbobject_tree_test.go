@@ -0,0 +1,293 @@
+package dbobject
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Returns the script joined from the structure
+func joinTestTree(t *testing.T, dir string) string {
+
+	t.Helper()
+
+	var joined bytes.Buffer
+	if err := JoinTree(dir, &joined); err != nil {
+		t.Fatal(err)
+	}
+
+	return joined.String()
+}
+
+// Checks that the statements are found in the script in given order
+func checkScriptOrder(t *testing.T, script string, statements ...string) {
+
+	t.Helper()
+
+	last := -1
+
+	for _, stmt := range statements {
+
+		pos := strings.Index(script, stmt)
+		if pos < 0 {
+			t.Errorf("%s not found in the script", stmt)
+			continue
+		}
+
+		if pos < last {
+			t.Errorf("%s found out of order", stmt)
+		}
+		last = pos
+	}
+}
+
+func TestJoinTree(t *testing.T) {
+
+	for _, mode := range []string{"origin", "custom"} {
+
+		dir := splitTestDump(t, Config{Mode: mode, Prologue: "file", DumpInfo: true}, readCorpusDump(t, "pg_dump_16.sql"))
+		script := joinTestTree(t, dir)
+
+		if strings.Contains(script, dumpInfoFileName) {
+			t.Errorf("%s: metadata file joined into the script", mode)
+		}
+
+		// every sql file is present exactly once
+		for path, content := range readTestTree(t, dir) {
+
+			if !strings.HasSuffix(path, ".sql") {
+				continue
+			}
+
+			if strings.Count(script, "-- File: "+path+"\n") != 1 {
+				t.Errorf("%s: file %s not joined exactly once", mode, path)
+			}
+
+			if !strings.Contains(script, strings.TrimRight(content, "\n")) {
+				t.Errorf("%s: content of %s not found in the script", mode, path)
+			}
+		}
+
+		if !strings.HasPrefix(script, "--\n-- File: "+prologueFileName+"\n") {
+			t.Errorf("%s: script does not start with the prologue: %.100q", mode, script)
+		}
+
+		// the dump of a single database is not connected
+		if strings.Contains(script, "\\connect") {
+			t.Errorf("%s: unexpected \\connect in the script", mode)
+		}
+
+		// objects are created after the objects they depend on
+		checkScriptOrder(t, script,
+			"SET statement_timeout = 0;",
+			"CREATE SCHEMA billing;",
+			"CREATE EXTENSION IF NOT EXISTS pgcrypto",
+			"CREATE TYPE billing.invoice_status",
+			"CREATE FUNCTION billing.total(p_invoice integer)",
+			"CREATE TABLE billing.invoice (",
+			"CREATE VIEW billing.open_invoice",
+		)
+
+		if mode == "origin" {
+			checkScriptOrder(t, script,
+				"CREATE TABLE billing.invoice (",
+				"ATTACH PARTITION billing.event_2024_01",
+				"ADD CONSTRAINT invoice_pkey PRIMARY KEY",
+				"CREATE INDEX invoice_status_idx",
+				"CREATE TRIGGER invoice_audit",
+				"ADD CONSTRAINT event_invoice_id_fkey FOREIGN KEY",
+				"CREATE POLICY invoice_tenant",
+				"COMMENT ON COLUMN billing.invoice.total",
+				"GRANT SELECT ON TABLE billing.invoice TO app_ro;",
+			)
+		}
+	}
+}
+
+func TestJoinTreeDatabases(t *testing.T) {
+
+	for _, mode := range []string{"origin", "custom"} {
+
+		script := joinTestTree(t, splitTestDump(t, Config{Mode: mode, Prologue: "file"}, readCorpusDump(t, "pg_dumpall_16.sql")))
+
+		// roles of the cluster, then the database created before it's connected
+		checkScriptOrder(t, script,
+			"CREATE ROLE app;",
+			"CREATE TABLESPACE archive",
+			"\\connect postgres\n",
+			"--\n-- File: postgres/"+prologueFileName+"\n",
+			"CREATE DATABASE shop ",
+			"\\connect shop\n",
+			"--\n-- File: shop/"+prologueFileName+"\n",
+			"CREATE SCHEMA billing;",
+			"CREATE TABLE billing.invoice (",
+			"\\connect template1\n",
+		)
+
+		if strings.Count(script, "\\connect") != 3 {
+			t.Errorf("%s: expected \\connect of each database", mode)
+		}
+
+		// databases without files of their own are not connected
+		script = joinTestTree(t, splitTestDump(t, Config{Mode: mode}, readCorpusDump(t, "pg_dumpall_16.sql")))
+
+		checkScriptOrder(t, script, "CREATE ROLE app;", "CREATE DATABASE shop ", "\\connect shop\n", "CREATE SCHEMA billing;")
+
+		if strings.Count(script, "\\connect") != 1 {
+			t.Errorf("%s: expected \\connect of the only database with files", mode)
+		}
+	}
+}
+
+// The joined script is a dump the splitter reads again. Without headers of objects, their statements are unclassified content,
+// which is expected in the database the script connects.
+func TestJoinTreeSplitAgain(t *testing.T) {
+
+	dir := splitTestDump(t, Config{Mode: "custom"}, readCorpusDump(t, "pg_dumpall_16.sql"))
+	script := joinTestTree(t, dir)
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", Unclassified: "file"}, script))
+
+	for path, content := range readTestTree(t, dir) {
+
+		if !strings.HasSuffix(path, ".sql") || strings.HasSuffix(path, prologueFileName) {
+			continue
+		}
+
+		// files of the cluster, or of the database
+		db, _, _ := strings.Cut(path, "/")
+		expected := tree[db+"/"+unclassifiedFileName]
+		if db == "-" {
+			expected = tree[unclassifiedFileName]
+		}
+
+		// the database is created before it's connected, so not in the database itself
+		created := strings.Contains(path, "/-/database/")
+
+		for _, line := range strings.Split(content, "\n") {
+			if line = strings.TrimSpace(line); line != "" && strings.Contains(expected, line) == created {
+				t.Errorf("line of %s found in wrong database: %s", path, line)
+			}
+		}
+	}
+
+	shop := tree["shop/"+unclassifiedFileName]
+	checkScriptOrder(t, shop, "CREATE SCHEMA billing;", "CREATE TYPE billing.invoice_status", "CREATE TABLE billing.invoice (")
+}
+
+func TestSortJoinedFiles(t *testing.T) {
+
+	files := []string{
+		unclassifiedFileName,
+		"billing/table/invoice.acl.sql",
+		"billing/table/invoice.partitions.sql",
+		"billing/table/invoice.sql",
+		"billing/billing.sql",
+		"billing/notes.txt.sql",
+		"_foreign/server/remote.sql",
+		"_extensions/pgcrypto.sql",
+		"billing/FK CONSTRAINT/invoice_fkey.sql",
+		"billing/fk_constraint/event_fkey.sql",
+		prologueFileName,
+		"-/DATABASE/shop.sql",
+	}
+
+	sortJoinedFiles(files)
+
+	expected := []string{
+		"-/DATABASE/shop.sql",
+		prologueFileName,
+		"billing/billing.sql",
+		"_extensions/pgcrypto.sql",
+		"_foreign/server/remote.sql",
+		"billing/table/invoice.sql",
+		"billing/table/invoice.partitions.sql",
+		"billing/FK CONSTRAINT/invoice_fkey.sql",
+		"billing/fk_constraint/event_fkey.sql",
+		"billing/table/invoice.acl.sql",
+		"billing/notes.txt.sql",
+		unclassifiedFileName,
+	}
+
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("got %v, expected %v", files, expected)
+	}
+}
+
+func TestConnectCommand(t *testing.T) {
+
+	for dbname, expected := range map[string]string{
+		"shop":          `\connect shop`,
+		"Shop":          `\connect -reuse-previous=on "dbname='Shop'"`,
+		"it's \\ \"x\"": `\connect -reuse-previous=on "dbname='it\'s \\ ""x""'"`,
+	} {
+		if got := connectCommand(dbname); got != expected {
+			t.Errorf("%q: got %s, expected %s", dbname, got, expected)
+		}
+	}
+}
+
+func TestDiffTrees(t *testing.T) {
+
+	a := splitTestDump(t, Config{Mode: "custom"}, testDumpCreate)
+	b := splitTestDump(t, Config{Mode: "custom"}, testDumpCreate)
+
+	diff, err := DiffTrees(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !diff.Empty() {
+		t.Fatalf("structures of the same dump differ: %+v", diff)
+	}
+
+	files, err := listTreeFiles(a)
+	if err != nil || len(files) < 2 {
+		t.Fatalf("unexpected files of the structure: %v %v", files, err)
+	}
+
+	if err := os.Remove(filepath.Join(a, filepath.FromSlash(files[0]))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(b, filepath.FromSlash(files[1])), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(a, "new.sql"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err = DiffTrees(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &TreeDiff{Added: []string{files[0]}, Removed: []string{"new.sql"}, Changed: []string{files[1]}}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("unexpected differences %+v, expected %+v", diff, expected)
+	}
+
+	var text bytes.Buffer
+	if err := diff.Print(&text, "text"); err != nil {
+		t.Fatal(err)
+	}
+
+	if text.String() != "- new.sql\n+ "+files[0]+"\n~ "+files[1]+"\n" {
+		t.Errorf("unexpected text output %q", text.String())
+	}
+
+	var js bytes.Buffer
+	if err := diff.Print(&js, "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded TreeDiff
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || !reflect.DeepEqual(&decoded, expected) {
+		t.Errorf("unexpected json output %s", js.String())
+	}
+}
@@ -0,0 +1,166 @@
+package dbobject
+
+import (
+	"fmt"
+	"pgdump_splitter/output"
+)
+
+// Describes how objects of given type are related to other objects
+type objGrouping int
+
+const (
+	// Object stands on its own
+	groupOwn objGrouping = iota
+	// Name of the object is prefixed by type of the related object, ie `TABLE orders` (comments, acls)
+	groupNamePrefixed
+	// Name of the object consists of the name of the related object and its own name, ie `orders orders_pkey`
+	groupParentName
+	// Related table is found in the content of the object
+	groupIndex
+	// Requires individual treatment implemented in normalizeDbObject
+	groupSpecial
+)
+
+// Registry entry of an object type
+type objTypeInfo struct {
+	grouping objGrouping
+	// type of the related object
+	parent string
+}
+
+// Registry of object types found in TOC entries of pg_dump (-- Name: ...; Type: ...;)
+// and in cluster sections of pg_dumpall
+var objTypes = map[string]objTypeInfo{
+	"ACCESS METHOD":                {grouping: groupOwn},
+	"ACL":                          {grouping: groupNamePrefixed},
+	"AGGREGATE":                    {grouping: groupOwn},
+	"BLOB":                         {grouping: groupOwn},
+	"BLOBS":                        {grouping: groupOwn},
+	"CAST":                         {grouping: groupOwn},
+	"CHECK CONSTRAINT":             {grouping: groupParentName, parent: "TABLE"},
+	"COLLATION":                    {grouping: groupOwn},
+	"COMMENT":                      {grouping: groupNamePrefixed},
+	"CONSTRAINT":                   {grouping: groupParentName, parent: "TABLE"},
+	"CONVERSION":                   {grouping: groupOwn},
+	"DATABASE":                     {grouping: groupOwn},
+	"DATABASE PROPERTIES":          {grouping: groupSpecial, parent: "DATABASE"},
+	"DEFAULT":                      {grouping: groupParentName, parent: "TABLE"},
+	"DEFAULT ACL":                  {grouping: groupOwn},
+	"DOMAIN":                       {grouping: groupOwn},
+	"DROP":                         {grouping: groupOwn},
+	"EVENT TRIGGER":                {grouping: groupOwn},
+	"EXTENSION":                    {grouping: groupOwn},
+	"FK CONSTRAINT":                {grouping: groupParentName, parent: "TABLE"},
+	"FOREIGN DATA WRAPPER":         {grouping: groupOwn},
+	"FOREIGN TABLE":                {grouping: groupOwn},
+	"FUNCTION":                     {grouping: groupOwn},
+	"INDEX":                        {grouping: groupIndex, parent: "TABLE"},
+	"INDEX ATTACH":                 {grouping: groupOwn},
+	"LARGE OBJECT":                 {grouping: groupOwn},
+	"LARGE OBJECTS":                {grouping: groupOwn},
+	"MATERIALIZED VIEW":            {grouping: groupOwn},
+	"MATERIALIZED VIEW DATA":       {grouping: groupSpecial, parent: "MATERIALIZED VIEW"},
+	"OPERATOR":                     {grouping: groupOwn},
+	"OPERATOR CLASS":               {grouping: groupOwn},
+	"OPERATOR FAMILY":              {grouping: groupOwn},
+	"PARAMETER ACL":                {grouping: groupOwn},
+	"POLICY":                       {grouping: groupSpecial, parent: "TABLE"},
+	"PROCEDURAL LANGUAGE":          {grouping: groupOwn},
+	"PROCEDURE":                    {grouping: groupOwn},
+	"PUBLICATION":                  {grouping: groupOwn},
+	"PUBLICATION TABLE":            {grouping: groupSpecial, parent: "PUBLICATION"},
+	"PUBLICATION TABLES IN SCHEMA": {grouping: groupOwn},
+	"ROLE":                         {grouping: groupOwn},
+	"ROLE SETTINGS":                {grouping: groupOwn},
+	"ROW SECURITY":                 {grouping: groupSpecial, parent: "TABLE"},
+	"RULE":                         {grouping: groupSpecial, parent: "TABLE"},
+	"SCHEMA":                       {grouping: groupOwn},
+	"SECURITY LABEL":               {grouping: groupOwn},
+	"SEQUENCE":                     {grouping: groupOwn},
+	"SEQUENCE OWNED BY":            {grouping: groupParentName, parent: "SEQUENCE"},
+	"SEQUENCE SET":                 {grouping: groupSpecial, parent: "SEQUENCE"},
+	"SERVER":                       {grouping: groupOwn},
+	"SHELL TYPE":                   {grouping: groupOwn},
+	"STATISTICS":                   {grouping: groupOwn},
+	"STATISTICS DATA":              {grouping: groupOwn},
+	"SUBSCRIPTION":                 {grouping: groupOwn},
+	"SUBSCRIPTION TABLE":           {grouping: groupOwn},
+	"TABLE":                        {grouping: groupOwn},
+	"TABLE ATTACH":                 {grouping: groupOwn},
+	"TABLE DATA":                   {grouping: groupOwn},
+	"TABLESPACE":                   {grouping: groupOwn},
+	"TEXT SEARCH CONFIGURATION":    {grouping: groupOwn},
+	"TEXT SEARCH DICTIONARY":       {grouping: groupOwn},
+	"TEXT SEARCH PARSER":           {grouping: groupOwn},
+	"TEXT SEARCH TEMPLATE":         {grouping: groupOwn},
+	"TRANSFORM":                    {grouping: groupOwn},
+	"TRIGGER":                      {grouping: groupParentName, parent: "TABLE"},
+	"TYPE":                         {grouping: groupOwn},
+	"USER MAPPING":                 {grouping: groupSpecial, parent: "SERVER"},
+	"VIEW":                         {grouping: groupOwn},
+}
+
+// Views found in the dump, keyed by database, schema and name.
+// Rules might belong either to a table or to a view, which can't be told by the name of the rule.
+var knownViews map[string]bool
+
+func initKnownViews() {
+	knownViews = make(map[string]bool)
+}
+
+// Records the object, if it's a view
+func recordView(dbo *DbObject) {
+
+	if knownViews != nil && (dbo.ObjType == "VIEW" || dbo.ObjType == "MATERIALIZED VIEW") {
+		knownViews[dbo.Database+"."+dbo.Schema+"."+dbo.Name] = true
+	}
+}
+
+// Returns type of the relation of given name, which might be either a table or a view
+func relationType(dbo *DbObject, name string) string {
+
+	if knownViews[dbo.Database+"."+dbo.Schema+"."+name] {
+		return "VIEW"
+	}
+
+	return "TABLE"
+}
+
+// If set, REFRESH MATERIALIZED VIEW statements are not stored
+var skipMatviewData bool
+
+// If set, unknown object types are reported as errors instead of warnings
+var strictTypes bool
+
+// If set, spaces in names of object type directories are replaced by underscores, ie `MATERIALIZED_VIEW`
+var typeDirsUnderscored bool
+
+func initTypeDirs(args *Config) error {
+
+	switch args.TypeDirs {
+	case "", "spaces":
+		typeDirsUnderscored = false
+	case "underscores":
+		typeDirsUnderscored = true
+	default:
+		return fmt.Errorf("invalid value of -type-dirs: %s", args.TypeDirs)
+	}
+
+	return nil
+}
+
+// Reports object types missing in the registry. In strict mode, the unknown type is an error.
+func checkObjType(dbo *DbObject) error {
+
+	if _, known := objTypes[dbo.ObjType]; known || dbo.ObjType == "" {
+		return nil
+	}
+
+	if strictTypes {
+		return fmt.Errorf("unknown object type %q of %q found on line %d", dbo.ObjType, dbo.Name, dbo.Line)
+	}
+
+	output.Log.Warn("unknown object type", "type", dbo.ObjType, "name", dbo.Name, "schema", dbo.Schema, "line", dbo.Line)
+
+	return nil
+}
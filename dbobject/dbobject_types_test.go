@@ -0,0 +1,194 @@
+package dbobject
+
+import (
+	"bufio"
+	"pgdump_splitter/output"
+	"strings"
+	"testing"
+)
+
+// Dump containing a type unknown to the splitter next to the known ones
+const testDumpUnknownType = `--
+-- Name: orders; Type: TABLE; Schema: public; Owner: app
+--
+
+CREATE TABLE public.orders (
+    id integer NOT NULL
+);
+
+
+--
+-- Name: orders orders_own; Type: POLICY; Schema: public; Owner: app
+--
+
+CREATE POLICY orders_own ON public.orders USING (true);
+
+
+--
+-- Name: shop_graph; Type: PROPERTY GRAPH; Schema: public; Owner: app
+--
+
+CREATE PROPERTY GRAPH public.shop_graph VERTEX TABLES (public.orders);
+
+`
+
+func TestUnknownTypeWarning(t *testing.T) {
+
+	var sb strings.Builder
+
+	defaultLog := output.Log
+	defer func() { output.Log = defaultLog }()
+
+	if err := output.SetupLog(&sb, "warn", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := splitTestDump(t, Config{Mode: "custom"}, testDumpUnknownType)
+
+	logged := sb.String()
+
+	if want := `"msg":"unknown object type","type":"PROPERTY GRAPH","name":"shop_graph","schema":"public","line":18`; !strings.Contains(logged, want) {
+		t.Errorf("log does not contain %s, got: %s", want, logged)
+	}
+
+	if strings.Count(logged, "unknown object type") != 1 {
+		t.Errorf("only the unknown type should be reported, got: %s", logged)
+	}
+
+	if _, found := readTestTree(t, dir)["public/property graph/shop_graph.sql"]; !found {
+		t.Errorf("object of unknown type should be stored")
+	}
+}
+
+func TestUnknownTypeStrict(t *testing.T) {
+
+	args := Config{Mode: "custom", Dest: t.TempDir(), BufS: 1024 * 1024, Progress: "off", Strict: true}
+
+	scanner := bufio.NewScanner(strings.NewReader(testDumpUnknownType))
+	scanner.Split(preserveNewlines)
+
+	if err := IsExclObjTypeOk(""); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ProcessStream(&args, scanner)
+
+	if err == nil || !strings.Contains(err.Error(), `"PROPERTY GRAPH"`) || !strings.Contains(err.Error(), "line 18") {
+		t.Errorf("expected error reporting the unknown type and its line, got: %v", err)
+	}
+}
+
+// Policies and rules of the billing.invoice table and the billing.open_invoice view of the testdata corpus
+func TestPoliciesAndRulesCustom(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom"}, readCorpusDump(t, "pg_dump_16.sql")))
+
+	for path := range tree {
+		if strings.Contains(path, "polic") || strings.Contains(path, "rule") || strings.Contains(path, "row_security") || strings.Contains(path, "comment") {
+			t.Errorf("unexpected file %s", path)
+		}
+	}
+
+	table := tree["billing/table/invoice.sql"]
+
+	for _, want := range []string{
+		"CREATE TABLE billing.invoice",
+		"CREATE RULE invoice_del AS\n    ON DELETE TO billing.invoice DO INSTEAD NOTHING;",
+		"COMMENT ON RULE invoice_del ON billing.invoice IS 'invoices are never deleted';",
+		"ALTER TABLE billing.invoice ENABLE ROW LEVEL SECURITY;",
+		"CREATE POLICY invoice_tenant ON billing.invoice USING ((tenant = CURRENT_USER));",
+		"COMMENT ON POLICY invoice_tenant ON billing.invoice IS 'rows of the current tenant';",
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("table file does not contain %s, got: %s", want, table)
+		}
+	}
+
+	if view := tree["billing/view/open_invoice.sql"]; !strings.Contains(view, "CREATE RULE open_invoice_ins AS") || strings.Contains(table, "open_invoice_ins") {
+		t.Errorf("rule of the view should be stored with the view, got: %s", view)
+	}
+}
+
+func TestPoliciesAndRulesOrigin(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "origin"}, readCorpusDump(t, "pg_dump_16.sql")))
+
+	for _, want := range []string{
+		"billing/POLICY/invoice invoice_tenant.sql",
+		"billing/RULE/invoice invoice_del.sql",
+		"billing/RULE/open_invoice open_invoice_ins.sql",
+		"billing/ROW SECURITY/invoice.sql",
+		"billing/COMMENT/POLICY invoice_tenant ON invoice.sql",
+	} {
+		if _, found := tree[want]; !found {
+			t.Errorf("expected file %s in origin mode, got: %v", want, tree)
+		}
+	}
+}
+
+// Part of the plain dump created by pg_dump 16 with a materialized view
+const testDumpMatview = `--
+-- Name: daily_sales; Type: MATERIALIZED VIEW; Schema: public; Owner: app
+--
+
+CREATE MATERIALIZED VIEW public.daily_sales AS
+ SELECT 1 AS total
+  WITH NO DATA;
+
+
+ALTER MATERIALIZED VIEW public.daily_sales OWNER TO app;
+
+--
+-- Name: daily_sales; Type: MATERIALIZED VIEW DATA; Schema: public; Owner: app
+--
+
+REFRESH MATERIALIZED VIEW public.daily_sales;
+
+
+--
+-- PostgreSQL database dump complete
+--
+
+`
+
+func TestMatviewData(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom"}, testDumpMatview))
+
+	if view := tree["public/materialized view/daily_sales.sql"]; len(tree) != 1 || !strings.Contains(view, "REFRESH MATERIALIZED VIEW public.daily_sales;") {
+		t.Errorf("refresh should be appended to the view file, got: %v", tree)
+	}
+
+	tree = readTestTree(t, splitTestDump(t, Config{Mode: "custom", SkipMatviewData: true}, testDumpMatview))
+
+	if view := tree["public/materialized view/daily_sales.sql"]; len(tree) != 1 || strings.Contains(view, "REFRESH") {
+		t.Errorf("refresh should be skipped, got: %v", tree)
+	}
+
+	tree = readTestTree(t, splitTestDump(t, Config{Mode: "origin"}, testDumpMatview))
+
+	if _, found := tree["public/MATERIALIZED VIEW DATA/daily_sales.sql"]; !found {
+		t.Errorf("origin mode should keep the data entry separate, got: %v", tree)
+	}
+}
+
+func TestTypeDirsUnderscores(t *testing.T) {
+
+	tree := readTestTree(t, splitTestDump(t, Config{Mode: "custom", TypeDirs: "underscores"}, testDumpMatview))
+
+	if _, found := tree["public/materialized_view/daily_sales.sql"]; len(tree) != 1 || !found {
+		t.Errorf("custom mode: unexpected structure %v", tree)
+	}
+
+	tree = readTestTree(t, splitTestDump(t, Config{Mode: "origin", TypeDirs: "underscores"}, testDumpMatview))
+
+	for _, path := range []string{"public/MATERIALIZED_VIEW/daily_sales.sql", "public/MATERIALIZED_VIEW_DATA/daily_sales.sql"} {
+		if _, found := tree[path]; len(tree) != 2 || !found {
+			t.Errorf("origin mode: file %s not found, got %v", path, tree)
+		}
+	}
+
+	if err := initTypeDirs(&Config{TypeDirs: "dashes"}); err == nil {
+		t.Errorf("invalid value of -type-dirs accepted")
+	}
+}
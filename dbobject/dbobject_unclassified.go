@@ -0,0 +1,194 @@
+package dbobject
+
+import (
+	"fmt"
+	"path/filepath"
+	"pgdump_splitter/output"
+	"strings"
+)
+
+// Name of the file content which can't be assigned to any object file is written to, placed in the database directory
+const unclassifiedFileName = "_unclassified.sql"
+
+// Reasons of content being unclassified, as reported in warnings and statistics
+const (
+	unclassifiedOrphan     = "orphan content"
+	unclassifiedEmptyPath  = "empty path"
+	unclassifiedUnparsable = "unparseable name"
+)
+
+// Tracks content of the dump, which doesn't get into a file of an object:
+// lines found outside of any object, objects without a path and objects whose names couldn't be parsed.
+// Every case is reported as a warning. In `file` mode, the content is written to {database}/_unclassified.sql
+type unclassifiedContent struct {
+	mode string
+	root string
+	nodb bool
+
+	// orphan lines collected until next object or section of the dump starts
+	orphan     strings.Builder
+	orphanDb   string
+	orphanLine int
+	orphanLast int
+	// length of collected content up to the last orphan line, comments following it belong to the next object
+	orphanEnd int
+}
+
+var unclassified *unclassifiedContent
+
+func initUnclassified(args *Config) error {
+
+	switch args.Unclassified {
+	case "", "warn", "file":
+	default:
+		return fmt.Errorf("invalid value of unclassified mode: %s", args.Unclassified)
+	}
+
+	unclassified = &unclassifiedContent{mode: args.Unclassified, root: args.Dest, nodb: args.NoDb}
+
+	return nil
+}
+
+// Tells whether the line found outside of any object carries content, which would be lost.
+// Empty lines, comments and session settings of the preamble are expected there.
+func isOrphanLine(line string) bool {
+
+	trimmed := strings.TrimSpace(line)
+
+	return trimmed != "" && !strings.HasPrefix(trimmed, "--") && !rgx_prologue.MatchString(line)
+}
+
+// Collects the line found outside of any object. Orphan lines are collected until flushOrphans is called,
+// empty lines and comments between them are kept.
+func (uc *unclassifiedContent) addLine(dbname string, lineno int, line *string) {
+
+	if uc == nil {
+		return
+	}
+
+	if uc.orphan.Len() == 0 {
+
+		if !isOrphanLine(*line) {
+			return
+		}
+
+		uc.orphanDb = dbname
+		uc.orphanLine = lineno
+	}
+
+	uc.orphan.WriteString(*line)
+
+	if isOrphanLine(*line) {
+		uc.orphanLast = lineno
+		uc.orphanEnd = uc.orphan.Len()
+	}
+}
+
+// Reports collected orphan lines and writes them down in `file` mode
+func (uc *unclassifiedContent) flushOrphans() error {
+
+	if uc == nil || uc.orphan.Len() == 0 {
+		return nil
+	}
+
+	content := uc.orphan.String()[:uc.orphanEnd]
+	uc.orphan.Reset()
+
+	output.Log.Warn("unclassified content", "reason", unclassifiedOrphan, "line", uc.orphanLine, "last_line", uc.orphanLast, "database", uc.orphanDb)
+	stats.addUnclassified(unclassifiedOrphan)
+
+	if uc.mode != "file" {
+		return nil
+	}
+
+	comment := fmt.Sprintf("lines %d-%d: %s", uc.orphanLine, uc.orphanLast, unclassifiedOrphan)
+	if uc.orphanLine == uc.orphanLast {
+		comment = fmt.Sprintf("line %d: %s", uc.orphanLine, unclassifiedOrphan)
+	}
+
+	return uc.write(uc.orphanDb, comment, strings.Trim(content, " \n")+"\n")
+}
+
+// Tells why the object, with its path generated, can't be stored into a file of its own. Empty string if it can.
+// Names of objects related to other ones are expected to contain names of both of them in custom mode.
+func (dbo *DbObject) unclassifiedReason() string {
+
+	if dbo.Paths.FullPath == "" {
+		return unclassifiedEmptyPath
+	}
+
+	// empty names, including functions without arguments in parentheses
+	if dbo.Name == "" || dbo.Paths.NameForFile == "" {
+		return unclassifiedUnparsable
+	}
+
+	if !dbo.Paths.IsCustom || dbo.ObjSubtype != "" {
+		return ""
+	}
+
+	switch objTypes[dbo.ObjType].grouping {
+	case groupNamePrefixed:
+		return unclassifiedUnparsable
+	case groupParentName:
+		// OWNED BY statements are named by the sequence only
+		if dbo.ObjType != "SEQUENCE OWNED BY" {
+			return unclassifiedUnparsable
+		}
+	}
+
+	return ""
+}
+
+// Reports the object, which can't be stored into a file of its own.
+// Returns true if the object has been written to the file of unclassified content (in `file` mode).
+// Otherwise, the caller stores the object if it has a path.
+func (uc *unclassifiedContent) storeObject(dbo *DbObject, reason string) (bool, error) {
+
+	if uc == nil {
+		return false, nil
+	}
+
+	output.Log.Warn("unclassified object", "reason", reason, "line", dbo.Line, "type", dbo.ObjType, "name", dbo.Name, "schema", dbo.Schema, "database", dbo.Database, "path", dbo.Paths.FullPath)
+	stats.addUnclassified(reason)
+
+	if uc.mode != "file" {
+		return false, nil
+	}
+
+	dbname := dbo.ownDatabase()
+
+	comment := fmt.Sprintf("line %d: %s; Type: %s; Schema: %s; Name: %s", dbo.Line, reason, dbo.ObjType, dbo.Schema, dbo.Name)
+
+	path, err := uc.path(dbname)
+	if err != nil {
+		return true, err
+	}
+
+	if storing != nil {
+		storing(dbo, path)
+	}
+
+	return true, uc.write(dbname, comment, dbo.fileContent())
+}
+
+// Returns path of the file of unclassified content of the database
+func (uc *unclassifiedContent) path(dbname string) (string, error) {
+
+	var dbpath string
+	if !uc.nodb {
+		dbpath = EscapePathComponent(dbname)
+	}
+
+	return resolveOutputPath(uc.root, filepath.Join(uc.root, dbpath, unclassifiedFileName))
+}
+
+// Appends the content preceded by a comment with its origin to the file of unclassified content of the database
+func (uc *unclassifiedContent) write(dbname string, comment string, content string) error {
+
+	path, err := uc.path(dbname)
+	if err != nil {
+		return err
+	}
+
+	return appendOutputFile(path, dbname, "--\n-- "+comment+"\n--\n\n"+content, "UNCLASSIFIED")
+}
@@ -0,0 +1,161 @@
+package dbobject
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDumpUnclassified = `--
+-- PostgreSQL database dump
+--
+
+-- Dumped from database version 16.2
+-- Dumped by pg_dump version 16.2
+
+SET statement_timeout = 0;
+SET client_encoding = 'UTF8';
+
+CREATE TABLE public.lost (id integer);
+
+ALTER TABLE public.lost OWNER TO app;
+
+--
+-- Name: broken; Type: FUNCTION; Schema: public; Owner: app
+--
+
+CREATE FUNCTION public.broken() RETURNS integer
+    LANGUAGE sql
+    AS $$ SELECT 1 $$;
+
+
+--
+-- Name: lost_pkey; Type: CONSTRAINT; Schema: public; Owner: app
+--
+
+ALTER TABLE ONLY public.lost
+    ADD CONSTRAINT lost_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: invoice; Type: TABLE; Schema: public; Owner: app
+--
+
+CREATE TABLE public.invoice (id integer);
+
+
+--
+-- PostgreSQL database dump complete
+--
+
+`
+
+func TestUnclassifiedWarn(t *testing.T) {
+
+	dir := splitTestDump(t, Config{Mode: "custom"}, testDumpUnclassified)
+	tree := readTestTree(t, dir)
+
+	if stats.Unclassified[unclassifiedOrphan] != 1 || stats.Unclassified[unclassifiedUnparsable] != 2 {
+		t.Errorf("unexpected unclassified counts: %v", stats.Unclassified)
+	}
+
+	// objects with a path are stored as before, orphan lines are dropped
+	for _, path := range []string{"public/function.sql", "public/constraint/lost_pkey.sql", "public/table/invoice.sql"} {
+		if _, found := tree[path]; !found {
+			t.Errorf("file %s not found", path)
+		}
+	}
+
+	if _, found := tree[unclassifiedFileName]; found {
+		t.Errorf("file %s not expected", unclassifiedFileName)
+	}
+}
+
+func TestUnclassifiedFile(t *testing.T) {
+
+	dir := splitTestDump(t, Config{Mode: "custom", Unclassified: "file"}, testDumpUnclassified)
+	tree := readTestTree(t, dir)
+
+	for _, path := range []string{"public/function.sql", "public/constraint/lost_pkey.sql"} {
+		if _, found := tree[path]; found {
+			t.Errorf("file %s not expected", path)
+		}
+	}
+
+	want := `--
+-- lines 11-13: orphan content
+--
+
+CREATE TABLE public.lost (id integer);
+
+ALTER TABLE public.lost OWNER TO app;
+
+--
+-- line 16: unparseable name; Type: FUNCTION; Schema: public; Name: broken
+--
+
+CREATE FUNCTION public.broken() RETURNS integer
+    LANGUAGE sql
+    AS $$ SELECT 1 $$;
+
+--
+-- line 25: unparseable name; Type: CONSTRAINT; Schema: public; Name: lost_pkey
+--
+
+ALTER TABLE ONLY public.lost
+    ADD CONSTRAINT lost_pkey PRIMARY KEY (id);
+`
+
+	if got := tree[unclassifiedFileName]; got != want {
+		t.Errorf("got %q, wants %q", got, want)
+	}
+
+	if got := tree["public/table/invoice.sql"]; got != "CREATE TABLE public.invoice (id integer);\n" {
+		t.Errorf("unexpected content of the table: %q", got)
+	}
+}
+
+func TestUnclassifiedNone(t *testing.T) {
+
+	dir := splitTestDump(t, Config{Mode: "custom", Unclassified: "file"}, testDumpRoles+testDumpCreate)
+
+	if len(stats.Unclassified) != 0 {
+		t.Errorf("unexpected unclassified content: %v", stats.Unclassified)
+	}
+
+	for path := range readTestTree(t, dir) {
+		if strings.HasSuffix(path, unclassifiedFileName) {
+			t.Errorf("file %s not expected", path)
+		}
+	}
+}
+
+func TestUnclassifiedReason(t *testing.T) {
+
+	for _, tc := range []struct {
+		objtype string
+		name    string
+		custom  bool
+		want    string
+	}{
+		{"TABLE", "invoice", true, ""},
+		{"TABLE", "", true, unclassifiedUnparsable},
+		{"FUNCTION", "total(integer)", false, ""},
+		{"FUNCTION", "total", false, unclassifiedUnparsable},
+		{"CONSTRAINT", "invoice invoice_pkey", true, ""},
+		{"CONSTRAINT", "invoice_pkey", true, unclassifiedUnparsable},
+		{"CONSTRAINT", "invoice_pkey", false, ""},
+		{"ACL", "TABLE invoice", true, ""},
+		{"ACL", "invoice", true, unclassifiedUnparsable},
+		{"SEQUENCE OWNED BY", "invoice_id_seq", true, ""},
+	} {
+
+		dbo := &DbObject{Database: "shop", Schema: "billing", ObjType: tc.objtype, Name: tc.name, Paths: DbObjPath{Rootpath: "structure", IsCustom: tc.custom}}
+
+		dbo.normalizeDbObject()
+		dbo.generateDestinationPath()
+
+		if got := dbo.unclassifiedReason(); got != tc.want {
+			t.Errorf("%s %q: got %q, wants %q", tc.objtype, tc.name, got, tc.want)
+		}
+	}
+}
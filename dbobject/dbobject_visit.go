@@ -0,0 +1,66 @@
+package dbobject
+
+import (
+	"bufio"
+	"errors"
+)
+
+// Returned by a callback of the visitor to stop reading the dump
+var ErrStopVisit = errors.New("visiting stopped")
+
+// Callbacks invoked by VisitStream
+type Visitor struct {
+	// called for every object allowed by filters, in order of appearance in the dump
+	Object func(dbo *DbObject) error
+	// called when the dump of a database is complete. Optional
+	DatabaseEnd func(dbname string) error
+}
+
+// Set while VisitStream reads the dump, objects allowed by filters are passed to the visitor instead of being stored
+var visiting *Visitor
+
+// Reads the dump and passes objects to the visitor, without writing anything.
+// The dump is read by ProcessStream, so objects are recognized and filtered by databases and object types (-exclude-objects)
+// the same way as they are when the dump is split. Sections of pg_dumpall globals are split into objects, ie one per role.
+// Objects are not normalized, their Line is the line of the object header.
+func VisitStream(args *Config, scanner *bufio.Scanner, visitor Visitor) error {
+
+	cfg := *args
+	cfg.DryRun = true
+	cfg.DryRunFormat = ""
+	cfg.Progress = "off"
+	cfg.MvRl = false
+
+	visiting = &visitor
+	defer func() { visiting = nil }()
+
+	if err := ProcessStream(&cfg, scanner); err != nil && !errors.Is(err, ErrStopVisit) {
+		return err
+	}
+
+	return nil
+}
+
+// Creates scanner of the dump given by the configuration and visits its objects
+func VisitDump(args *Config, visitor Visitor) error {
+	return readDump(args, func(scanner *bufio.Scanner) error {
+		return VisitStream(args, scanner, visitor)
+	})
+}
+
+// Creates scanner of the dump given by the configuration and passes it to the reading function
+func readDump(args *Config, read func(scanner *bufio.Scanner) error) error {
+
+	var dataprov ScanerProvider
+
+	if err := IsExclObjTypeOk(args.ExOT); err != nil {
+		return err
+	}
+
+	if err := dataprov.CreateScanner(args); err != nil {
+		return err
+	}
+	defer dataprov.Finalize()
+
+	return read(dataprov.scanner)
+}
@@ -0,0 +1,40 @@
+#!/bin/sh
+# Captures the dumps of testdata/dumps from PostgreSQL 12 - 18 servers run by docker, ie
+# `dbobject/testdata/corpus/capture.sh dbobject/testdata/dumps`.
+# Images of latest minor releases are used, so \restrict lines are present in all dumps.
+# Goldens are regenerated afterwards by `go test ./dbobject -run TestGolden -update`.
+set -eu
+
+dst=${1:?usage: capture.sh {directory}}
+here=$(cd "$(dirname "$0")" && pwd)
+
+for v in 12 13 14 15 16 17 18; do
+
+	name=pgdump-splitter-corpus-$v
+
+	docker run -d --rm --name "$name" -e POSTGRES_HOST_AUTH_METHOD=trust "postgres:$v" >/dev/null
+	trap 'docker stop "$name" >/dev/null' EXIT
+
+	# the server started by initialization of the image doesn't listen on tcp
+	until docker exec "$name" pg_isready -q -h 127.0.0.1 -U postgres; do
+		sleep 1
+	done
+
+	docker exec -u postgres "$name" mkdir -p /var/lib/postgresql/archive
+	docker exec -i -u postgres "$name" psql -q -X -v ON_ERROR_STOP=1 -U postgres < "$here/schema.sql"
+
+	create=
+	case $v in
+	12 | 15 | 18) create=--create ;;
+	esac
+
+	docker exec -u postgres "$name" pg_dump $create shop > "$dst/pg_dump_$v.sql"
+	if [ "$v" = 16 ]; then
+		docker exec -u postgres "$name" pg_dump tenants > "$dst/pg_dump_tenants_$v.sql"
+	fi
+	docker exec -u postgres "$name" pg_dumpall > "$dst/pg_dumpall_$v.sql"
+	docker exec -u postgres "$name" pg_dumpall --globals-only --clean > "$dst/pg_dumpall_globals_clean_$v.sql"
+
+	docker stop "$name" >/dev/null
+	trap - EXIT
+done
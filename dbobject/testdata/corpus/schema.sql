@@ -0,0 +1,146 @@
+-- Cluster and the shop database of the test corpus, see capture.sh
+
+CREATE ROLE app LOGIN;
+CREATE ROLE app_ro NOLOGIN;
+CREATE ROLE "Data Team" NOLOGIN;
+COMMENT ON ROLE app_ro IS 'read only access';
+ALTER ROLE app SET search_path TO 'billing', 'public';
+GRANT app_ro TO app, "Data Team";
+
+SELECT current_setting('server_version_num')::integer >= 150000 AS parameter_acl \gset
+\if :parameter_acl
+GRANT SET ON PARAMETER work_mem TO app;
+\endif
+
+CREATE TABLESPACE archive OWNER app LOCATION '/var/lib/postgresql/archive';
+
+CREATE DATABASE shop OWNER app TEMPLATE template0 ENCODING 'UTF8' LOCALE 'en_US.UTF-8';
+
+\connect shop
+
+CREATE EXTENSION pgcrypto;
+
+SET ROLE app;
+
+CREATE SCHEMA billing;
+CREATE SCHEMA "Sales";
+
+CREATE TYPE billing.invoice_status AS ENUM ('draft', 'issued', 'paid');
+
+CREATE TABLE billing.invoice (
+    id serial PRIMARY KEY,
+    status billing.invoice_status NOT NULL DEFAULT 'draft',
+    total numeric(12,2),
+    tenant text NOT NULL DEFAULT CURRENT_USER,
+    modified timestamp with time zone
+);
+
+COMMENT ON COLUMN billing.invoice.total IS 'gross total';
+
+CREATE INDEX invoice_status_idx ON billing.invoice (status) WHERE status <> 'paid';
+
+CREATE FUNCTION billing.total(p_invoice integer) RETURNS numeric
+    LANGUAGE sql STABLE
+    AS $$
+  SELECT total FROM billing.invoice WHERE id = p_invoice;
+$$;
+
+CREATE FUNCTION billing.total(p_invoice integer, p_currency character varying DEFAULT 'EUR') RETURNS numeric
+    LANGUAGE sql STABLE
+    AS $$
+  SELECT billing.total(p_invoice);
+$$;
+
+COMMENT ON FUNCTION billing.total(integer, character varying) IS 'total of the invoice in the currency';
+
+CREATE PROCEDURE billing.close_invoice(IN p_id integer)
+    LANGUAGE sql
+    AS $$
+  UPDATE billing.invoice SET status = 'paid' WHERE id = p_id;
+$$;
+
+CREATE FUNCTION billing.audit_invoice() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  NEW.modified := now();
+  RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER invoice_audit BEFORE UPDATE ON billing.invoice FOR EACH ROW EXECUTE FUNCTION billing.audit_invoice();
+
+CREATE TABLE billing.event (
+    id bigint NOT NULL,
+    invoice_id integer REFERENCES billing.invoice (id),
+    created timestamp with time zone NOT NULL,
+    PRIMARY KEY (id, created)
+) PARTITION BY RANGE (created);
+
+CREATE INDEX event_created_idx ON billing.event (created);
+
+CREATE TABLE billing.event_2024_01 PARTITION OF billing.event FOR VALUES FROM ('2024-01-01 00:00:00+00') TO ('2024-02-01 00:00:00+00');
+CREATE TABLE billing.event_2024_02 PARTITION OF billing.event FOR VALUES FROM ('2024-02-01 00:00:00+00') TO ('2024-03-01 00:00:00+00');
+
+CREATE VIEW billing.open_invoice AS
+    SELECT id, total FROM billing.invoice WHERE status <> 'paid';
+
+CREATE TABLE "Sales"."Order Lines" (
+    "Line No" integer NOT NULL,
+    invoice_id integer NOT NULL REFERENCES billing.invoice (id),
+    amount numeric(12,2),
+    PRIMARY KEY (invoice_id, "Line No")
+);
+
+COMMENT ON COLUMN "Sales"."Order Lines"."Line No" IS 'position on the invoice';
+
+CREATE RULE invoice_del AS ON DELETE TO billing.invoice DO INSTEAD NOTHING;
+COMMENT ON RULE invoice_del ON billing.invoice IS 'invoices are never deleted';
+CREATE RULE open_invoice_ins AS ON INSERT TO billing.open_invoice DO INSTEAD INSERT INTO billing.invoice (id, total) VALUES (new.id, new.total);
+
+ALTER TABLE billing.invoice ENABLE ROW LEVEL SECURITY;
+CREATE POLICY invoice_tenant ON billing.invoice USING (tenant = CURRENT_USER);
+COMMENT ON POLICY invoice_tenant ON billing.invoice IS 'rows of the current tenant';
+
+GRANT USAGE ON SCHEMA billing TO app_ro;
+REVOKE ALL ON FUNCTION billing.total(integer) FROM PUBLIC;
+GRANT ALL ON FUNCTION billing.total(integer) TO app_ro;
+GRANT SELECT ON TABLE billing.invoice TO app_ro;
+GRANT SELECT ON TABLE "Sales"."Order Lines" TO app_ro;
+ALTER DEFAULT PRIVILEGES FOR ROLE app IN SCHEMA billing GRANT SELECT ON TABLES TO app_ro;
+
+-- database of one schema per tenant, tenant_0010 deviates by an extra column and tenant_0012 by a missing index
+
+\connect postgres
+
+CREATE DATABASE tenants OWNER app TEMPLATE template0 ENCODING 'UTF8' LOCALE 'en_US.UTF-8';
+
+\connect tenants
+
+SET ROLE app;
+
+CREATE SCHEMA shared;
+
+CREATE TABLE shared.currency (
+    code character(3) PRIMARY KEY,
+    name text NOT NULL
+);
+
+GRANT USAGE ON SCHEMA shared TO app_ro;
+
+SELECT format('CREATE SCHEMA %1$I;
+CREATE TABLE %1$I.orders (
+    id serial PRIMARY KEY,
+    customer text NOT NULL,
+    amount numeric(12,2),
+    currency character(3) NOT NULL DEFAULT ''EUR'' REFERENCES shared.currency (code)%2$s
+);
+COMMENT ON TABLE %1$I.orders IS ''orders of the tenant'';
+%3$s
+GRANT USAGE ON SCHEMA %1$I TO app_ro;
+GRANT SELECT ON TABLE %1$I.orders TO app_ro;',
+    s,
+    CASE WHEN s = 'tenant_0010' THEN ',
+    note text' ELSE '' END,
+    CASE WHEN s = 'tenant_0012' THEN '' ELSE format('CREATE INDEX orders_customer_idx ON %I.orders (customer);', s) END)
+FROM unnest(ARRAY['tenant_0001', 'tenant_0002', 'tenant_0010', 'tenant_0011', 'tenant_0012']) AS s \gexec
@@ -0,0 +1,481 @@
+--
+-- PostgreSQL database dump
+--
+
+-- Dumped from database version 12.22 (Debian 12.22-1.pgdg120+1)
+-- Dumped by pg_dump version 12.22 (Debian 12.22-1.pgdg120+1)
+
+SET statement_timeout = 0;
+SET lock_timeout = 0;
+SET idle_in_transaction_session_timeout = 0;
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+SELECT pg_catalog.set_config('search_path', '', false);
+SET check_function_bodies = false;
+SET xmloption = content;
+SET client_min_messages = warning;
+SET row_security = off;
+
+--
+-- Name: shop; Type: DATABASE; Schema: -; Owner: app
+--
+
+CREATE DATABASE shop WITH TEMPLATE = template0 ENCODING = 'UTF8' LOCALE = 'en_US.UTF-8';
+
+
+ALTER DATABASE shop OWNER TO app;
+
+\connect shop
+
+SET statement_timeout = 0;
+SET lock_timeout = 0;
+SET idle_in_transaction_session_timeout = 0;
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+SELECT pg_catalog.set_config('search_path', '', false);
+SET check_function_bodies = false;
+SET xmloption = content;
+SET client_min_messages = warning;
+SET row_security = off;
+
+--
+-- Name: billing; Type: SCHEMA; Schema: -; Owner: app
+--
+
+CREATE SCHEMA billing;
+
+
+ALTER SCHEMA billing OWNER TO app;
+
+--
+-- Name: Sales; Type: SCHEMA; Schema: -; Owner: app
+--
+
+CREATE SCHEMA "Sales";
+
+
+ALTER SCHEMA "Sales" OWNER TO app;
+
+--
+-- Name: pgcrypto; Type: EXTENSION; Schema: -; Owner: -
+--
+
+CREATE EXTENSION IF NOT EXISTS pgcrypto WITH SCHEMA public;
+
+
+--
+-- Name: EXTENSION pgcrypto; Type: COMMENT; Schema: -; Owner: 
+--
+
+COMMENT ON EXTENSION pgcrypto IS 'cryptographic functions';
+
+
+--
+-- Name: invoice_status; Type: TYPE; Schema: billing; Owner: app
+--
+
+CREATE TYPE billing.invoice_status AS ENUM (
+    'draft',
+    'issued',
+    'paid'
+);
+
+
+ALTER TYPE billing.invoice_status OWNER TO app;
+
+--
+-- Name: close_invoice(IN p_id integer); Type: PROCEDURE; Schema: billing; Owner: app
+--
+
+CREATE PROCEDURE billing.close_invoice(IN p_id integer)
+    LANGUAGE sql
+    AS $$
+  UPDATE billing.invoice SET status = 'paid' WHERE id = p_id;
+$$;
+
+
+ALTER PROCEDURE billing.close_invoice(IN p_id integer) OWNER TO app;
+
+--
+-- Name: total(p_invoice integer); Type: FUNCTION; Schema: billing; Owner: app
+--
+
+CREATE FUNCTION billing.total(p_invoice integer) RETURNS numeric
+    LANGUAGE sql STABLE
+    AS $$
+  SELECT total FROM billing.invoice WHERE id = p_invoice;
+$$;
+
+
+ALTER FUNCTION billing.total(p_invoice integer) OWNER TO app;
+
+--
+-- Name: total(p_invoice integer, p_currency character varying); Type: FUNCTION; Schema: billing; Owner: app
+--
+
+CREATE FUNCTION billing.total(p_invoice integer, p_currency character varying DEFAULT 'EUR'::character varying) RETURNS numeric
+    LANGUAGE sql STABLE
+    AS $$
+  SELECT billing.total(p_invoice);
+$$;
+
+
+ALTER FUNCTION billing.total(p_invoice integer, p_currency character varying) OWNER TO app;
+
+--
+-- Name: FUNCTION total(p_invoice integer, p_currency character varying); Type: COMMENT; Schema: billing; Owner: app
+--
+
+COMMENT ON FUNCTION billing.total(p_invoice integer, p_currency character varying) IS 'total of the invoice in the currency';
+
+
+--
+-- Name: audit_invoice(); Type: FUNCTION; Schema: billing; Owner: app
+--
+
+CREATE FUNCTION billing.audit_invoice() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  NEW.modified := now();
+  RETURN NEW;
+END;
+$$;
+
+
+ALTER FUNCTION billing.audit_invoice() OWNER TO app;
+
+SET default_tablespace = '';
+
+SET default_table_access_method = heap;
+
+--
+-- Name: event; Type: TABLE; Schema: billing; Owner: app
+--
+
+CREATE TABLE billing.event (
+    id bigint NOT NULL,
+    invoice_id integer,
+    created timestamp with time zone NOT NULL
+)
+PARTITION BY RANGE (created);
+
+
+ALTER TABLE billing.event OWNER TO app;
+
+--
+-- Name: event_2024_01; Type: TABLE; Schema: billing; Owner: app
+--
+
+CREATE TABLE billing.event_2024_01 (
+    id bigint NOT NULL,
+    invoice_id integer,
+    created timestamp with time zone NOT NULL
+);
+ALTER TABLE ONLY billing.event ATTACH PARTITION billing.event_2024_01 FOR VALUES FROM ('2024-01-01 00:00:00+00') TO ('2024-02-01 00:00:00+00');
+
+
+ALTER TABLE billing.event_2024_01 OWNER TO app;
+
+--
+-- Name: event_2024_02; Type: TABLE; Schema: billing; Owner: app
+--
+
+CREATE TABLE billing.event_2024_02 (
+    id bigint NOT NULL,
+    invoice_id integer,
+    created timestamp with time zone NOT NULL
+);
+ALTER TABLE ONLY billing.event ATTACH PARTITION billing.event_2024_02 FOR VALUES FROM ('2024-02-01 00:00:00+00') TO ('2024-03-01 00:00:00+00');
+
+
+ALTER TABLE billing.event_2024_02 OWNER TO app;
+
+--
+-- Name: invoice; Type: TABLE; Schema: billing; Owner: app
+--
+
+CREATE TABLE billing.invoice (
+    id integer NOT NULL,
+    status billing.invoice_status DEFAULT 'draft'::billing.invoice_status NOT NULL,
+    total numeric(12,2),
+    tenant text DEFAULT CURRENT_USER NOT NULL,
+    modified timestamp with time zone
+);
+
+
+ALTER TABLE billing.invoice OWNER TO app;
+
+--
+-- Name: COLUMN invoice.total; Type: COMMENT; Schema: billing; Owner: app
+--
+
+COMMENT ON COLUMN billing.invoice.total IS 'gross total';
+
+
+--
+-- Name: invoice_id_seq; Type: SEQUENCE; Schema: billing; Owner: app
+--
+
+CREATE SEQUENCE billing.invoice_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER SEQUENCE billing.invoice_id_seq OWNER TO app;
+
+--
+-- Name: invoice_id_seq; Type: SEQUENCE OWNED BY; Schema: billing; Owner: app
+--
+
+ALTER SEQUENCE billing.invoice_id_seq OWNED BY billing.invoice.id;
+
+
+--
+-- Name: open_invoice; Type: VIEW; Schema: billing; Owner: app
+--
+
+CREATE VIEW billing.open_invoice AS
+ SELECT invoice.id,
+    invoice.total
+   FROM billing.invoice
+  WHERE (invoice.status <> 'paid'::billing.invoice_status);
+
+
+ALTER TABLE billing.open_invoice OWNER TO app;
+
+--
+-- Name: Order Lines; Type: TABLE; Schema: Sales; Owner: app
+--
+
+CREATE TABLE "Sales"."Order Lines" (
+    "Line No" integer NOT NULL,
+    invoice_id integer NOT NULL,
+    amount numeric(12,2)
+);
+
+
+ALTER TABLE "Sales"."Order Lines" OWNER TO app;
+
+--
+-- Name: COLUMN "Order Lines"."Line No"; Type: COMMENT; Schema: Sales; Owner: app
+--
+
+COMMENT ON COLUMN "Sales"."Order Lines"."Line No" IS 'position on the invoice';
+
+
+--
+-- Name: invoice id; Type: DEFAULT; Schema: billing; Owner: app
+--
+
+ALTER TABLE ONLY billing.invoice ALTER COLUMN id SET DEFAULT nextval('billing.invoice_id_seq'::regclass);
+
+
+--
+-- Name: Order Lines Order Lines_pkey; Type: CONSTRAINT; Schema: Sales; Owner: app
+--
+
+ALTER TABLE ONLY "Sales"."Order Lines"
+    ADD CONSTRAINT "Order Lines_pkey" PRIMARY KEY (invoice_id, "Line No");
+
+
+--
+-- Name: event event_pkey; Type: CONSTRAINT; Schema: billing; Owner: app
+--
+
+ALTER TABLE ONLY billing.event
+    ADD CONSTRAINT event_pkey PRIMARY KEY (id, created);
+
+
+--
+-- Name: event_2024_01 event_2024_01_pkey; Type: CONSTRAINT; Schema: billing; Owner: app
+--
+
+ALTER TABLE ONLY billing.event_2024_01
+    ADD CONSTRAINT event_2024_01_pkey PRIMARY KEY (id, created);
+
+
+--
+-- Name: event_2024_02 event_2024_02_pkey; Type: CONSTRAINT; Schema: billing; Owner: app
+--
+
+ALTER TABLE ONLY billing.event_2024_02
+    ADD CONSTRAINT event_2024_02_pkey PRIMARY KEY (id, created);
+
+
+--
+-- Name: invoice invoice_pkey; Type: CONSTRAINT; Schema: billing; Owner: app
+--
+
+ALTER TABLE ONLY billing.invoice
+    ADD CONSTRAINT invoice_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: event_created_idx; Type: INDEX; Schema: billing; Owner: app
+--
+
+CREATE INDEX event_created_idx ON ONLY billing.event USING btree (created);
+
+
+--
+-- Name: event_2024_01_created_idx; Type: INDEX; Schema: billing; Owner: app
+--
+
+CREATE INDEX event_2024_01_created_idx ON billing.event_2024_01 USING btree (created);
+
+
+--
+-- Name: event_2024_02_created_idx; Type: INDEX; Schema: billing; Owner: app
+--
+
+CREATE INDEX event_2024_02_created_idx ON billing.event_2024_02 USING btree (created);
+
+
+--
+-- Name: invoice_status_idx; Type: INDEX; Schema: billing; Owner: app
+--
+
+CREATE INDEX invoice_status_idx ON billing.invoice USING btree (status) WHERE (status <> 'paid'::billing.invoice_status);
+
+
+--
+-- Name: event_2024_01_created_idx; Type: INDEX ATTACH; Schema: billing; Owner: -
+--
+
+ALTER INDEX billing.event_created_idx ATTACH PARTITION billing.event_2024_01_created_idx;
+
+
+--
+-- Name: event_2024_01_pkey; Type: INDEX ATTACH; Schema: billing; Owner: -
+--
+
+ALTER INDEX billing.event_pkey ATTACH PARTITION billing.event_2024_01_pkey;
+
+
+--
+-- Name: event_2024_02_created_idx; Type: INDEX ATTACH; Schema: billing; Owner: -
+--
+
+ALTER INDEX billing.event_created_idx ATTACH PARTITION billing.event_2024_02_created_idx;
+
+
+--
+-- Name: event_2024_02_pkey; Type: INDEX ATTACH; Schema: billing; Owner: -
+--
+
+ALTER INDEX billing.event_pkey ATTACH PARTITION billing.event_2024_02_pkey;
+
+
+--
+-- Name: invoice invoice_del; Type: RULE; Schema: billing; Owner: app
+--
+
+CREATE RULE invoice_del AS
+    ON DELETE TO billing.invoice DO INSTEAD NOTHING;
+
+
+--
+-- Name: RULE invoice_del ON invoice; Type: COMMENT; Schema: billing; Owner: app
+--
+
+COMMENT ON RULE invoice_del ON billing.invoice IS 'invoices are never deleted';
+
+
+--
+-- Name: open_invoice open_invoice_ins; Type: RULE; Schema: billing; Owner: app
+--
+
+CREATE RULE open_invoice_ins AS
+    ON INSERT TO billing.open_invoice DO INSTEAD  INSERT INTO billing.invoice (id, total)
+  VALUES (new.id, new.total);
+
+
+--
+-- Name: invoice invoice_audit; Type: TRIGGER; Schema: billing; Owner: app
+--
+
+CREATE TRIGGER invoice_audit BEFORE UPDATE ON billing.invoice FOR EACH ROW EXECUTE FUNCTION billing.audit_invoice();
+
+
+--
+-- Name: event event_invoice_id_fkey; Type: FK CONSTRAINT; Schema: billing; Owner: app
+--
+
+ALTER TABLE billing.event
+    ADD CONSTRAINT event_invoice_id_fkey FOREIGN KEY (invoice_id) REFERENCES billing.invoice(id);
+
+
+--
+-- Name: Order Lines Order Lines_invoice_id_fkey; Type: FK CONSTRAINT; Schema: Sales; Owner: app
+--
+
+ALTER TABLE ONLY "Sales"."Order Lines"
+    ADD CONSTRAINT "Order Lines_invoice_id_fkey" FOREIGN KEY (invoice_id) REFERENCES billing.invoice(id);
+
+
+--
+-- Name: invoice; Type: ROW SECURITY; Schema: billing; Owner: app
+--
+
+ALTER TABLE billing.invoice ENABLE ROW LEVEL SECURITY;
+
+
+--
+-- Name: invoice invoice_tenant; Type: POLICY; Schema: billing; Owner: app
+--
+
+CREATE POLICY invoice_tenant ON billing.invoice USING ((tenant = CURRENT_USER));
+
+
+--
+-- Name: POLICY invoice_tenant ON invoice; Type: COMMENT; Schema: billing; Owner: app
+--
+
+COMMENT ON POLICY invoice_tenant ON billing.invoice IS 'rows of the current tenant';
+
+
+--
+-- Name: SCHEMA billing; Type: ACL; Schema: -; Owner: app
+--
+
+GRANT USAGE ON SCHEMA billing TO app_ro;
+
+
+--
+-- Name: FUNCTION total(p_invoice integer); Type: ACL; Schema: billing; Owner: app
+--
+
+REVOKE ALL ON FUNCTION billing.total(p_invoice integer) FROM PUBLIC;
+GRANT ALL ON FUNCTION billing.total(p_invoice integer) TO app_ro;
+
+
+--
+-- Name: TABLE invoice; Type: ACL; Schema: billing; Owner: app
+--
+
+GRANT SELECT ON TABLE billing.invoice TO app_ro;
+
+
+--
+-- Name: TABLE "Order Lines"; Type: ACL; Schema: Sales; Owner: app
+--
+
+GRANT SELECT ON TABLE "Sales"."Order Lines" TO app_ro;
+
+
+--
+-- Name: DEFAULT PRIVILEGES FOR TABLES; Type: DEFAULT ACL; Schema: billing; Owner: app
+--
+
+ALTER DEFAULT PRIVILEGES FOR ROLE app IN SCHEMA billing GRANT SELECT ON TABLES TO app_ro;
+
+
+--
+-- PostgreSQL database dump complete
+--
+
@@ -0,0 +1,536 @@
+--
+-- PostgreSQL database dump
+--
+
+-- Dumped from database version 16.9 (Debian 16.9-1.pgdg120+1)
+-- Dumped by pg_dump version 16.9 (Debian 16.9-1.pgdg120+1)
+
+SET statement_timeout = 0;
+SET lock_timeout = 0;
+SET idle_in_transaction_session_timeout = 0;
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+SELECT pg_catalog.set_config('search_path', '', false);
+SET check_function_bodies = false;
+SET xmloption = content;
+SET client_min_messages = warning;
+SET row_security = off;
+
+--
+-- Name: shared; Type: SCHEMA; Schema: -; Owner: app
+--
+
+CREATE SCHEMA shared;
+
+
+ALTER SCHEMA shared OWNER TO app;
+
+--
+-- Name: tenant_0001; Type: SCHEMA; Schema: -; Owner: app
+--
+
+CREATE SCHEMA tenant_0001;
+
+
+ALTER SCHEMA tenant_0001 OWNER TO app;
+
+--
+-- Name: tenant_0002; Type: SCHEMA; Schema: -; Owner: app
+--
+
+CREATE SCHEMA tenant_0002;
+
+
+ALTER SCHEMA tenant_0002 OWNER TO app;
+
+--
+-- Name: tenant_0010; Type: SCHEMA; Schema: -; Owner: app
+--
+
+CREATE SCHEMA tenant_0010;
+
+
+ALTER SCHEMA tenant_0010 OWNER TO app;
+
+--
+-- Name: tenant_0011; Type: SCHEMA; Schema: -; Owner: app
+--
+
+CREATE SCHEMA tenant_0011;
+
+
+ALTER SCHEMA tenant_0011 OWNER TO app;
+
+--
+-- Name: tenant_0012; Type: SCHEMA; Schema: -; Owner: app
+--
+
+CREATE SCHEMA tenant_0012;
+
+
+ALTER SCHEMA tenant_0012 OWNER TO app;
+
+SET default_tablespace = '';
+
+SET default_table_access_method = heap;
+
+--
+-- Name: currency; Type: TABLE; Schema: shared; Owner: app
+--
+
+CREATE TABLE shared.currency (
+    code character(3) NOT NULL,
+    name text NOT NULL
+);
+
+
+ALTER TABLE shared.currency OWNER TO app;
+
+--
+-- Name: orders; Type: TABLE; Schema: tenant_0001; Owner: app
+--
+
+CREATE TABLE tenant_0001.orders (
+    id integer NOT NULL,
+    customer text NOT NULL,
+    amount numeric(12,2),
+    currency character(3) DEFAULT 'EUR'::bpchar NOT NULL
+);
+
+
+ALTER TABLE tenant_0001.orders OWNER TO app;
+
+--
+-- Name: TABLE orders; Type: COMMENT; Schema: tenant_0001; Owner: app
+--
+
+COMMENT ON TABLE tenant_0001.orders IS 'orders of the tenant';
+
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE; Schema: tenant_0001; Owner: app
+--
+
+CREATE SEQUENCE tenant_0001.orders_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER SEQUENCE tenant_0001.orders_id_seq OWNER TO app;
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE OWNED BY; Schema: tenant_0001; Owner: app
+--
+
+ALTER SEQUENCE tenant_0001.orders_id_seq OWNED BY tenant_0001.orders.id;
+
+
+--
+-- Name: orders; Type: TABLE; Schema: tenant_0002; Owner: app
+--
+
+CREATE TABLE tenant_0002.orders (
+    id integer NOT NULL,
+    customer text NOT NULL,
+    amount numeric(12,2),
+    currency character(3) DEFAULT 'EUR'::bpchar NOT NULL
+);
+
+
+ALTER TABLE tenant_0002.orders OWNER TO app;
+
+--
+-- Name: TABLE orders; Type: COMMENT; Schema: tenant_0002; Owner: app
+--
+
+COMMENT ON TABLE tenant_0002.orders IS 'orders of the tenant';
+
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE; Schema: tenant_0002; Owner: app
+--
+
+CREATE SEQUENCE tenant_0002.orders_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER SEQUENCE tenant_0002.orders_id_seq OWNER TO app;
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE OWNED BY; Schema: tenant_0002; Owner: app
+--
+
+ALTER SEQUENCE tenant_0002.orders_id_seq OWNED BY tenant_0002.orders.id;
+
+
+--
+-- Name: orders; Type: TABLE; Schema: tenant_0010; Owner: app
+--
+
+CREATE TABLE tenant_0010.orders (
+    id integer NOT NULL,
+    customer text NOT NULL,
+    amount numeric(12,2),
+    currency character(3) DEFAULT 'EUR'::bpchar NOT NULL,
+    note text
+);
+
+
+ALTER TABLE tenant_0010.orders OWNER TO app;
+
+--
+-- Name: TABLE orders; Type: COMMENT; Schema: tenant_0010; Owner: app
+--
+
+COMMENT ON TABLE tenant_0010.orders IS 'orders of the tenant';
+
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE; Schema: tenant_0010; Owner: app
+--
+
+CREATE SEQUENCE tenant_0010.orders_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER SEQUENCE tenant_0010.orders_id_seq OWNER TO app;
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE OWNED BY; Schema: tenant_0010; Owner: app
+--
+
+ALTER SEQUENCE tenant_0010.orders_id_seq OWNED BY tenant_0010.orders.id;
+
+
+--
+-- Name: orders; Type: TABLE; Schema: tenant_0011; Owner: app
+--
+
+CREATE TABLE tenant_0011.orders (
+    id integer NOT NULL,
+    customer text NOT NULL,
+    amount numeric(12,2),
+    currency character(3) DEFAULT 'EUR'::bpchar NOT NULL
+);
+
+
+ALTER TABLE tenant_0011.orders OWNER TO app;
+
+--
+-- Name: TABLE orders; Type: COMMENT; Schema: tenant_0011; Owner: app
+--
+
+COMMENT ON TABLE tenant_0011.orders IS 'orders of the tenant';
+
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE; Schema: tenant_0011; Owner: app
+--
+
+CREATE SEQUENCE tenant_0011.orders_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER SEQUENCE tenant_0011.orders_id_seq OWNER TO app;
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE OWNED BY; Schema: tenant_0011; Owner: app
+--
+
+ALTER SEQUENCE tenant_0011.orders_id_seq OWNED BY tenant_0011.orders.id;
+
+
+--
+-- Name: orders; Type: TABLE; Schema: tenant_0012; Owner: app
+--
+
+CREATE TABLE tenant_0012.orders (
+    id integer NOT NULL,
+    customer text NOT NULL,
+    amount numeric(12,2),
+    currency character(3) DEFAULT 'EUR'::bpchar NOT NULL
+);
+
+
+ALTER TABLE tenant_0012.orders OWNER TO app;
+
+--
+-- Name: TABLE orders; Type: COMMENT; Schema: tenant_0012; Owner: app
+--
+
+COMMENT ON TABLE tenant_0012.orders IS 'orders of the tenant';
+
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE; Schema: tenant_0012; Owner: app
+--
+
+CREATE SEQUENCE tenant_0012.orders_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER SEQUENCE tenant_0012.orders_id_seq OWNER TO app;
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE OWNED BY; Schema: tenant_0012; Owner: app
+--
+
+ALTER SEQUENCE tenant_0012.orders_id_seq OWNED BY tenant_0012.orders.id;
+
+
+--
+-- Name: orders id; Type: DEFAULT; Schema: tenant_0001; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0001.orders ALTER COLUMN id SET DEFAULT nextval('tenant_0001.orders_id_seq'::regclass);
+
+
+--
+-- Name: orders id; Type: DEFAULT; Schema: tenant_0002; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0002.orders ALTER COLUMN id SET DEFAULT nextval('tenant_0002.orders_id_seq'::regclass);
+
+
+--
+-- Name: orders id; Type: DEFAULT; Schema: tenant_0010; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0010.orders ALTER COLUMN id SET DEFAULT nextval('tenant_0010.orders_id_seq'::regclass);
+
+
+--
+-- Name: orders id; Type: DEFAULT; Schema: tenant_0011; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0011.orders ALTER COLUMN id SET DEFAULT nextval('tenant_0011.orders_id_seq'::regclass);
+
+
+--
+-- Name: orders id; Type: DEFAULT; Schema: tenant_0012; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0012.orders ALTER COLUMN id SET DEFAULT nextval('tenant_0012.orders_id_seq'::regclass);
+
+
+--
+-- Name: currency currency_pkey; Type: CONSTRAINT; Schema: shared; Owner: app
+--
+
+ALTER TABLE ONLY shared.currency
+    ADD CONSTRAINT currency_pkey PRIMARY KEY (code);
+
+
+--
+-- Name: orders orders_pkey; Type: CONSTRAINT; Schema: tenant_0001; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0001.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: orders orders_pkey; Type: CONSTRAINT; Schema: tenant_0002; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0002.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: orders orders_pkey; Type: CONSTRAINT; Schema: tenant_0010; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0010.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: orders orders_pkey; Type: CONSTRAINT; Schema: tenant_0011; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0011.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: orders orders_pkey; Type: CONSTRAINT; Schema: tenant_0012; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0012.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: orders_customer_idx; Type: INDEX; Schema: tenant_0001; Owner: app
+--
+
+CREATE INDEX orders_customer_idx ON tenant_0001.orders USING btree (customer);
+
+
+--
+-- Name: orders_customer_idx; Type: INDEX; Schema: tenant_0002; Owner: app
+--
+
+CREATE INDEX orders_customer_idx ON tenant_0002.orders USING btree (customer);
+
+
+--
+-- Name: orders_customer_idx; Type: INDEX; Schema: tenant_0010; Owner: app
+--
+
+CREATE INDEX orders_customer_idx ON tenant_0010.orders USING btree (customer);
+
+
+--
+-- Name: orders_customer_idx; Type: INDEX; Schema: tenant_0011; Owner: app
+--
+
+CREATE INDEX orders_customer_idx ON tenant_0011.orders USING btree (customer);
+
+
+--
+-- Name: orders orders_currency_fkey; Type: FK CONSTRAINT; Schema: tenant_0001; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0001.orders
+    ADD CONSTRAINT orders_currency_fkey FOREIGN KEY (currency) REFERENCES shared.currency(code);
+
+
+--
+-- Name: orders orders_currency_fkey; Type: FK CONSTRAINT; Schema: tenant_0002; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0002.orders
+    ADD CONSTRAINT orders_currency_fkey FOREIGN KEY (currency) REFERENCES shared.currency(code);
+
+
+--
+-- Name: orders orders_currency_fkey; Type: FK CONSTRAINT; Schema: tenant_0010; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0010.orders
+    ADD CONSTRAINT orders_currency_fkey FOREIGN KEY (currency) REFERENCES shared.currency(code);
+
+
+--
+-- Name: orders orders_currency_fkey; Type: FK CONSTRAINT; Schema: tenant_0011; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0011.orders
+    ADD CONSTRAINT orders_currency_fkey FOREIGN KEY (currency) REFERENCES shared.currency(code);
+
+
+--
+-- Name: orders orders_currency_fkey; Type: FK CONSTRAINT; Schema: tenant_0012; Owner: app
+--
+
+ALTER TABLE ONLY tenant_0012.orders
+    ADD CONSTRAINT orders_currency_fkey FOREIGN KEY (currency) REFERENCES shared.currency(code);
+
+
+--
+-- Name: SCHEMA shared; Type: ACL; Schema: -; Owner: app
+--
+
+GRANT USAGE ON SCHEMA shared TO app_ro;
+
+
+--
+-- Name: SCHEMA tenant_0001; Type: ACL; Schema: -; Owner: app
+--
+
+GRANT USAGE ON SCHEMA tenant_0001 TO app_ro;
+
+
+--
+-- Name: SCHEMA tenant_0002; Type: ACL; Schema: -; Owner: app
+--
+
+GRANT USAGE ON SCHEMA tenant_0002 TO app_ro;
+
+
+--
+-- Name: SCHEMA tenant_0010; Type: ACL; Schema: -; Owner: app
+--
+
+GRANT USAGE ON SCHEMA tenant_0010 TO app_ro;
+
+
+--
+-- Name: SCHEMA tenant_0011; Type: ACL; Schema: -; Owner: app
+--
+
+GRANT USAGE ON SCHEMA tenant_0011 TO app_ro;
+
+
+--
+-- Name: SCHEMA tenant_0012; Type: ACL; Schema: -; Owner: app
+--
+
+GRANT USAGE ON SCHEMA tenant_0012 TO app_ro;
+
+
+--
+-- Name: TABLE orders; Type: ACL; Schema: tenant_0001; Owner: app
+--
+
+GRANT SELECT ON TABLE tenant_0001.orders TO app_ro;
+
+
+--
+-- Name: TABLE orders; Type: ACL; Schema: tenant_0002; Owner: app
+--
+
+GRANT SELECT ON TABLE tenant_0002.orders TO app_ro;
+
+
+--
+-- Name: TABLE orders; Type: ACL; Schema: tenant_0010; Owner: app
+--
+
+GRANT SELECT ON TABLE tenant_0010.orders TO app_ro;
+
+
+--
+-- Name: TABLE orders; Type: ACL; Schema: tenant_0011; Owner: app
+--
+
+GRANT SELECT ON TABLE tenant_0011.orders TO app_ro;
+
+
+--
+-- Name: TABLE orders; Type: ACL; Schema: tenant_0012; Owner: app
+--
+
+GRANT SELECT ON TABLE tenant_0012.orders TO app_ro;
+
+
+--
+-- PostgreSQL database dump complete
+--
+
@@ -0,0 +1,74 @@
+--
+-- PostgreSQL database cluster dump
+--
+
+\restrict MQfHz4bEblGBDbXnScCARewP6xaSNTISlKfl80YdUYpbnd6vddxgIB9kPjo99RL
+
+SET default_transaction_read_only = off;
+
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+
+--
+-- Drop tablespaces
+--
+
+DROP TABLESPACE archive;
+
+
+--
+-- Drop roles
+--
+
+DROP ROLE "Data Team";
+DROP ROLE app;
+DROP ROLE app_ro;
+DROP ROLE postgres;
+
+
+--
+-- Roles
+--
+
+CREATE ROLE "Data Team";
+ALTER ROLE "Data Team" WITH NOSUPERUSER INHERIT NOCREATEROLE NOCREATEDB NOLOGIN NOREPLICATION NOBYPASSRLS;
+CREATE ROLE app;
+ALTER ROLE app WITH NOSUPERUSER INHERIT NOCREATEROLE NOCREATEDB LOGIN NOREPLICATION NOBYPASSRLS;
+CREATE ROLE app_ro;
+ALTER ROLE app_ro WITH NOSUPERUSER INHERIT NOCREATEROLE NOCREATEDB NOLOGIN NOREPLICATION NOBYPASSRLS;
+COMMENT ON ROLE app_ro IS 'read only access';
+CREATE ROLE postgres;
+ALTER ROLE postgres WITH SUPERUSER INHERIT CREATEROLE CREATEDB LOGIN REPLICATION BYPASSRLS;
+
+--
+-- User Configurations
+--
+
+--
+-- User Config "app"
+--
+
+ALTER ROLE app SET search_path TO 'billing', 'public';
+
+
+--
+-- Role memberships
+--
+
+GRANT app_ro TO "Data Team" GRANTED BY postgres;
+GRANT app_ro TO app GRANTED BY postgres;
+
+
+--
+-- Tablespaces
+--
+
+CREATE TABLESPACE archive OWNER app LOCATION '/var/lib/postgresql/archive';
+
+
+\unrestrict MQfHz4bEblGBDbXnScCARewP6xaSNTISlKfl80YdUYpbnd6vddxgIB9kPjo99RL
+
+--
+-- PostgreSQL database cluster dump complete
+--
+
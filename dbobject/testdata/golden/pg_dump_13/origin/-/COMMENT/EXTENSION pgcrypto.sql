@@ -0,0 +1 @@
+COMMENT ON EXTENSION pgcrypto IS 'cryptographic functions';
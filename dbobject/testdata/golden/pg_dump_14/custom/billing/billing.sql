@@ -0,0 +1,6 @@
+CREATE SCHEMA billing;
+
+
+ALTER SCHEMA billing OWNER TO app;
+
+GRANT USAGE ON SCHEMA billing TO app_ro;
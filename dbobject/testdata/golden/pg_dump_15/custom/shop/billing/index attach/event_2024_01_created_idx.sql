@@ -0,0 +1 @@
+ALTER INDEX billing.event_created_idx ATTACH PARTITION billing.event_2024_01_created_idx;
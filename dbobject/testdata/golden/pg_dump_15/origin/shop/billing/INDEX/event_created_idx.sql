@@ -0,0 +1 @@
+CREATE INDEX event_created_idx ON ONLY billing.event USING btree (created);
@@ -0,0 +1 @@
+CREATE INDEX event_2024_02_created_idx ON billing.event_2024_02 USING btree (created);
@@ -0,0 +1 @@
+ALTER TABLE billing.invoice ENABLE ROW LEVEL SECURITY;
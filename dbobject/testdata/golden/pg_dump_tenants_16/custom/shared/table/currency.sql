@@ -0,0 +1,10 @@
+CREATE TABLE shared.currency (
+    code character(3) NOT NULL,
+    name text NOT NULL
+);
+
+
+ALTER TABLE shared.currency OWNER TO app;
+
+ALTER TABLE ONLY shared.currency
+    ADD CONSTRAINT currency_pkey PRIMARY KEY (code);
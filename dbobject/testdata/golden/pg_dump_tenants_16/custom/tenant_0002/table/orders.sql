@@ -0,0 +1,23 @@
+CREATE TABLE tenant_0002.orders (
+    id integer NOT NULL,
+    customer text NOT NULL,
+    amount numeric(12,2),
+    currency character(3) DEFAULT 'EUR'::bpchar NOT NULL
+);
+
+
+ALTER TABLE tenant_0002.orders OWNER TO app;
+
+COMMENT ON TABLE tenant_0002.orders IS 'orders of the tenant';
+
+ALTER TABLE ONLY tenant_0002.orders ALTER COLUMN id SET DEFAULT nextval('tenant_0002.orders_id_seq'::regclass);
+
+ALTER TABLE ONLY tenant_0002.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
+
+CREATE INDEX orders_customer_idx ON tenant_0002.orders USING btree (customer);
+
+ALTER TABLE ONLY tenant_0002.orders
+    ADD CONSTRAINT orders_currency_fkey FOREIGN KEY (currency) REFERENCES shared.currency(code);
+
+GRANT SELECT ON TABLE tenant_0002.orders TO app_ro;
@@ -0,0 +1,6 @@
+CREATE SCHEMA tenant_0002;
+
+
+ALTER SCHEMA tenant_0002 OWNER TO app;
+
+GRANT USAGE ON SCHEMA tenant_0002 TO app_ro;
@@ -0,0 +1,12 @@
+CREATE SEQUENCE tenant_0011.orders_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER SEQUENCE tenant_0011.orders_id_seq OWNER TO app;
+
+ALTER SEQUENCE tenant_0011.orders_id_seq OWNED BY tenant_0011.orders.id;
@@ -0,0 +1,10 @@
+CREATE SCHEMA tenant_0012;
+
+
+ALTER SCHEMA tenant_0012 OWNER TO app;
+
+SET default_tablespace = '';
+
+SET default_table_access_method = heap;
+
+GRANT USAGE ON SCHEMA tenant_0012 TO app_ro;
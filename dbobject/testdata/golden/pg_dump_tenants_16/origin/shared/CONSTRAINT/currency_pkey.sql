@@ -0,0 +1,2 @@
+ALTER TABLE ONLY shared.currency
+    ADD CONSTRAINT currency_pkey PRIMARY KEY (code);
@@ -0,0 +1,7 @@
+CREATE TABLE shared.currency (
+    code character(3) NOT NULL,
+    name text NOT NULL
+);
+
+
+ALTER TABLE shared.currency OWNER TO app;
@@ -0,0 +1,6 @@
+CREATE SCHEMA shared;
+
+
+ALTER SCHEMA shared OWNER TO app;
+
+GRANT USAGE ON SCHEMA shared TO app_ro;
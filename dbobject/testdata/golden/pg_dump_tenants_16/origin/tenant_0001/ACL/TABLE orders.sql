@@ -0,0 +1 @@
+GRANT SELECT ON TABLE tenant_0001.orders TO app_ro;
@@ -0,0 +1 @@
+ALTER SEQUENCE tenant_0001.orders_id_seq OWNED BY tenant_0001.orders.id;
@@ -0,0 +1,9 @@
+CREATE TABLE tenant_0001.orders (
+    id integer NOT NULL,
+    customer text NOT NULL,
+    amount numeric(12,2),
+    currency character(3) DEFAULT 'EUR'::bpchar NOT NULL
+);
+
+
+ALTER TABLE tenant_0001.orders OWNER TO app;
@@ -0,0 +1,6 @@
+CREATE SCHEMA tenant_0001;
+
+
+ALTER SCHEMA tenant_0001 OWNER TO app;
+
+GRANT USAGE ON SCHEMA tenant_0001 TO app_ro;
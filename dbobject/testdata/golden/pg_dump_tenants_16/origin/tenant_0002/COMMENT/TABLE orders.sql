@@ -0,0 +1 @@
+COMMENT ON TABLE tenant_0002.orders IS 'orders of the tenant';
@@ -0,0 +1 @@
+ALTER TABLE ONLY tenant_0002.orders ALTER COLUMN id SET DEFAULT nextval('tenant_0002.orders_id_seq'::regclass);
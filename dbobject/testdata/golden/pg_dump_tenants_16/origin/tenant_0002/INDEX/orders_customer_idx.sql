@@ -0,0 +1 @@
+CREATE INDEX orders_customer_idx ON tenant_0002.orders USING btree (customer);
@@ -0,0 +1 @@
+ALTER SEQUENCE tenant_0002.orders_id_seq OWNED BY tenant_0002.orders.id;
@@ -0,0 +1,2 @@
+ALTER TABLE ONLY tenant_0010.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
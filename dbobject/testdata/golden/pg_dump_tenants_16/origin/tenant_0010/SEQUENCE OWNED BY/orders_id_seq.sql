@@ -0,0 +1 @@
+ALTER SEQUENCE tenant_0010.orders_id_seq OWNED BY tenant_0010.orders.id;
@@ -0,0 +1,10 @@
+CREATE TABLE tenant_0010.orders (
+    id integer NOT NULL,
+    customer text NOT NULL,
+    amount numeric(12,2),
+    currency character(3) DEFAULT 'EUR'::bpchar NOT NULL,
+    note text
+);
+
+
+ALTER TABLE tenant_0010.orders OWNER TO app;
@@ -0,0 +1,6 @@
+CREATE SCHEMA tenant_0010;
+
+
+ALTER SCHEMA tenant_0010 OWNER TO app;
+
+GRANT USAGE ON SCHEMA tenant_0010 TO app_ro;
@@ -0,0 +1 @@
+ALTER SEQUENCE tenant_0011.orders_id_seq OWNED BY tenant_0011.orders.id;
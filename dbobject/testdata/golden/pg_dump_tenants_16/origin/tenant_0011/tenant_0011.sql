@@ -0,0 +1,6 @@
+CREATE SCHEMA tenant_0011;
+
+
+ALTER SCHEMA tenant_0011 OWNER TO app;
+
+GRANT USAGE ON SCHEMA tenant_0011 TO app_ro;
@@ -0,0 +1 @@
+GRANT SELECT ON TABLE tenant_0012.orders TO app_ro;
@@ -0,0 +1 @@
+COMMENT ON TABLE tenant_0012.orders IS 'orders of the tenant';
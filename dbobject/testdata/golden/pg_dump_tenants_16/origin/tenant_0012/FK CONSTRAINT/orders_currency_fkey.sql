@@ -0,0 +1,2 @@
+ALTER TABLE ONLY tenant_0012.orders
+    ADD CONSTRAINT orders_currency_fkey FOREIGN KEY (currency) REFERENCES shared.currency(code);
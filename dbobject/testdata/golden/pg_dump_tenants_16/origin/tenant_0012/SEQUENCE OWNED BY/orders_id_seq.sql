@@ -0,0 +1 @@
+ALTER SEQUENCE tenant_0012.orders_id_seq OWNED BY tenant_0012.orders.id;
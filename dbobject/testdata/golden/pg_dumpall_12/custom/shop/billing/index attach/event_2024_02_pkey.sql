@@ -0,0 +1 @@
+ALTER INDEX billing.event_pkey ATTACH PARTITION billing.event_2024_02_pkey;
@@ -0,0 +1 @@
+COMMENT ON COLUMN "Sales"."Order Lines"."Line No" IS 'position on the invoice';
@@ -0,0 +1 @@
+GRANT SELECT ON TABLE billing.invoice TO app_ro;
@@ -0,0 +1 @@
+CREATE INDEX event_2024_01_created_idx ON billing.event_2024_01 USING btree (created);
@@ -0,0 +1 @@
+COMMENT ON COLUMN billing.invoice.total IS 'gross total';
@@ -0,0 +1 @@
+ALTER SEQUENCE billing.invoice_id_seq OWNED BY billing.invoice.id;
@@ -0,0 +1 @@
+GRANT SELECT ON TABLE "Sales"."Order Lines" TO app_ro;
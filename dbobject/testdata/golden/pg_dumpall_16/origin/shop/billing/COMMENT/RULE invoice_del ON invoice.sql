@@ -0,0 +1 @@
+COMMENT ON RULE invoice_del ON billing.invoice IS 'invoices are never deleted';
@@ -0,0 +1 @@
+CREATE POLICY invoice_tenant ON billing.invoice USING ((tenant = CURRENT_USER));
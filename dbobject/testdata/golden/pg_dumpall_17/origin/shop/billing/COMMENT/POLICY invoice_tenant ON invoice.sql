@@ -0,0 +1 @@
+COMMENT ON POLICY invoice_tenant ON billing.invoice IS 'rows of the current tenant';
@@ -0,0 +1 @@
+CREATE TABLESPACE archive OWNER app LOCATION '/var/lib/postgresql/archive';
@@ -0,0 +1,4 @@
+CREATE ROLE "Data Team";
+ALTER ROLE "Data Team" WITH NOSUPERUSER INHERIT NOCREATEROLE NOCREATEDB NOLOGIN NOREPLICATION NOBYPASSRLS;
+
+GRANT app_ro TO "Data Team" GRANTED BY postgres;
@@ -0,0 +1 @@
+DROP TABLESPACE archive;
@@ -0,0 +1,5 @@
+CREATE TABLESPACE archive OWNER app LOCATION '/var/lib/postgresql/archive';
+
+
+--
+-- PostgreSQL database cluster dump complete
@@ -0,0 +1,6 @@
+CREATE ROLE app;
+ALTER ROLE app WITH NOSUPERUSER INHERIT NOCREATEROLE NOCREATEDB LOGIN NOREPLICATION NOBYPASSRLS;
+
+ALTER ROLE app SET search_path TO 'billing', 'public';
+
+GRANT app_ro TO app WITH INHERIT TRUE GRANTED BY postgres;
@@ -0,0 +1 @@
+GRANT SET ON PARAMETER work_mem TO app;
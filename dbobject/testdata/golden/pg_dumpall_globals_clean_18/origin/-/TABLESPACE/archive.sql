@@ -0,0 +1,6 @@
+CREATE TABLESPACE archive OWNER app LOCATION '/var/lib/postgresql/archive';
+
+
+
+--
+-- PostgreSQL database cluster dump complete
@@ -23,43 +23,73 @@ func CreateFile(filefullpath string) (bool, error) {
 	return false, nil
 }
 
-// CopyDir copies the contents of a source directory to a destination directory recursively.
-func CopyDir(src, dest string) error {
+// Writes data to the file, creating the whole path needed for that file.
+// Existing file is overwritten.
+func WriteFile(filefullpath string, data []byte) error {
 
-	// Create destination directory if it doesn't exist
-	if err := os.MkdirAll(dest, os.ModePerm); err != nil {
+	if err := os.MkdirAll(filepath.Dir(filefullpath), 0770); err != nil {
 		return err
 	}
 
-	// Open source directory
-	dir, err := os.Open(src)
+	return os.WriteFile(filefullpath, data, 0660)
+}
+
+// Copies files of a source directory, for which keep function returns true, to a destination directory recursively.
+// Files are copied by given function (CopyFile, SymlinkFile or HardlinkFile).
+// Directories are created only if any of their files is copied.
+func CopyDirFiltered(src, dest string, keep func(path string) bool, copyfile func(src, dest string) error) error {
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+
+		if err != nil || info.IsDir() || !keep(path) {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		destFilePath := filepath.Join(dest, rel)
+
+		if err := os.MkdirAll(filepath.Dir(destFilePath), os.ModePerm); err != nil {
+			return err
+		}
+
+		return copyfile(path, destFilePath)
+	})
+}
+
+// Creates a symbolic link to the source file. The link is relative, so the whole structure might be moved.
+// Existing destination file is replaced.
+func SymlinkFile(src, dest string) error {
+
+	target, err := filepath.Rel(filepath.Dir(dest), src)
 	if err != nil {
 		return err
 	}
-	defer dir.Close()
 
-	// Read the contents of the source directory
-	fileInfos, err := dir.Readdir(-1)
-	if err != nil {
+	if err := removeIfExists(dest); err != nil {
 		return err
 	}
 
-	// Iterate over the files in the source directory
-	for _, fileInfo := range fileInfos {
-		srcFilePath := filepath.Join(src, fileInfo.Name())
-		destFilePath := filepath.Join(dest, fileInfo.Name())
+	return os.Symlink(target, dest)
+}
 
-		if fileInfo.IsDir() {
-			// Recursively copy subdirectories
-			if err := CopyDir(srcFilePath, destFilePath); err != nil {
-				return err
-			}
-		} else {
-			// Copy regular files
-			if err := CopyFile(srcFilePath, destFilePath); err != nil {
-				return err
-			}
-		}
+// Creates a hard link to the source file. Existing destination file is replaced.
+func HardlinkFile(src, dest string) error {
+
+	if err := removeIfExists(dest); err != nil {
+		return err
+	}
+
+	return os.Link(src, dest)
+}
+
+func removeIfExists(path string) error {
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
 	}
 
 	return nil
@@ -1,70 +1,156 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"log"
 	"os"
-	"pgdump_splitter/dbobject"
 	"pgdump_splitter/output"
 )
 
 var version = "0.0.0" // provided by build flag (VERSION file)
 
+// Exit codes of the program
+const (
+	exitOk          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitDifferences = 3
+)
+
+// Subcommand of the program
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands []command
+
+func init() {
+
+	commands = []command{
+		{name: "split", summary: "Splits a dump into a structure of files (default command)", run: runSplit},
+		{name: "join", summary: "Concatenates sql files of a structure into a single script", run: runJoin},
+		{name: "ls", summary: "Lists objects of a dump without writing any file", run: runLs},
+		{name: "show", summary: "Prints an object of a dump as it would be stored in custom mode", run: runShow},
+		{name: "diff", summary: "Compares two structures", run: runDiff},
+		{name: "verify", summary: "Checks that a structure is up to date with a dump", run: runVerify},
+	}
+}
+
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// Dispatches arguments to the subcommand. Without a subcommand, arguments are passed to split for backward compatibility
+func run(args []string) int {
 
-	var args dbobject.Config
-
-	flag.StringVar(&args.File, "f", "", "path to dump generated by pg_dump or pg_dumpall. If omited the program will expect data on stdin via system pipe.")
-	flag.StringVar(&args.Mode, "mode", "custom", "The mode of dumping db objects. origin - for file organization as present in the database dump. custom - reorganizes db objects storing related ones into single file")
-	flag.StringVar(&args.Dest, "dst", "structure", "Location where structures will be dumped to")
-	flag.BoolVar(&args.NoDb, "ndb", false, "No db name in destination path. It should not be set to true if multiple databases are dumped at once")
-	flag.StringVar(&args.ExDb, "blacklist-db", "^(template|postgres)", "Regular expression pattern allowing to skip extraction of matching databases. Usefull in case of processing dump files. In case of using a pipe from pg_dumpall, exclude them using pd_dumpall switch.")
-	flag.StringVar(&args.WlDb, "whitelist-db", "", "Regular expression pattern allowing to whitelist databases. If set, only databases matching this expression will be processed")
-	flag.BoolVar(&args.MvRl, "mc", false, "Move dump of roles into each database subdirectory")
-	flag.IntVar(&args.BufS, "buffer", 1024*1024, "Set up maximum buffer sizze if your dump contains data not feeting the scanner")
-	flag.BoolVar(&args.Cln, "clean", false, "If true, it will wipe out the content of the destination directory. Otherwise will attempt to add new files")
-	flag.BoolVar(&args.Quiet, "quiet", false, "If true, no information is outputed to std out")
-	flag.BoolVar(&args.AclFiles, "aclfiles", false, "Applicable or mode=custom only. Makes GRANTs to be outputed to separate files suffixed with .acl.sql, ie table_name.acl.sql. Otherwise acls are appended to related object file.")
-	flag.StringVar(&args.ExOT, "exclude-objects", "", "Regular expression pattern allowing to skip extraction of matching database objects. The expression is matched against TYPE value found in the dumped SQL")
-	flag.StringVar(&args.Restrict, "restrict", "", "Restrict hash that supports restricted mode introduced in postgresql 17.6. Without this option every restrict/unrestrict line will be skipped")
-	flag.Bool("version", false, "Show program version")
-
-	flag.Parse()
-
-	output.Quiet = args.Quiet
-
-	if isFlagPassed("version") {
-		fmt.Printf("pgdump_splitter %s\n", version)
-		return
+	if len(args) == 0 {
+		printUsage()
+		return exitUsage
 	}
 
-	if flag.NFlag() == 0 {
-		flag.PrintDefaults()
-		return
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		printUsage()
+		return exitOk
 	}
 
-	if !(args.Mode == "" || args.Mode == "custom" || args.Mode == "origin") {
-		fmt.Fprintln(os.Stderr, "Invalid value passed to `mode` modifier")
-		if !args.Quiet {
-			flag.PrintDefaults()
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
 		}
-		return
 	}
 
-	err := dbobject.StartProcessing(&args)
-	if err != nil {
-		log.Fatalf("Finished with error: %s", err.Error())
+	return runSplit(args)
+}
+
+func printUsage() {
+
+	w := flag.CommandLine.Output()
+
+	fmt.Fprintf(w, "Usage: pgdump_splitter [command] [flags]\n\nCommands:\n")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintf(w, "\nRun 'pgdump_splitter command -h' for flags of the command.\n")
+}
+
+// Creates set of flags of the subcommand. Parsing errors are reported, not handled by exiting
+func newFlagSet(name string, usage string) *flag.FlagSet {
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: pgdump_splitter %s %s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// Parses flags of the subcommand. Returns exit code and false if the command should not continue
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOk, false
+		}
+		return exitUsage, false
 	}
 
-	// Print the output
-	output.Println("Finished")
+	return exitOk, true
+}
+
+// Reports invalid usage of the subcommand
+func usageError(fs *flag.FlagSet, format string, a ...any) int {
+
+	fmt.Fprintf(os.Stderr, "pgdump_splitter %s: %s\n", fs.Name(), fmt.Sprintf(format, a...))
+	fs.Usage()
+
+	return exitUsage
+}
+
+// Reports failure of the subcommand
+func failure(name string, err error) int {
+
+	fmt.Fprintf(os.Stderr, "pgdump_splitter %s: %s\n", name, err.Error())
+
+	return exitError
+}
+
+// Flags controlling diagnostic messages, shared by subcommands
+type logFlags struct {
+	level  string
+	format string
+}
+
+func addLogFlags(fs *flag.FlagSet) *logFlags {
+
+	lf := &logFlags{}
+
+	fs.StringVar(&lf.level, "log-level", "warn", "Level of diagnostic messages written to stderr: debug, info, warn or error. Debug level traces every parsing decision. Defaults to error if -quiet is set")
+	fs.StringVar(&lf.format, "log-format", "text", "Format of diagnostic messages: text or json")
+
+	return lf
+}
+
+func (lf *logFlags) setup(fs *flag.FlagSet, quiet bool) error {
+
+	level := lf.level
+	if quiet && !isFlagPassed(fs, "log-level") {
+		level = "error"
+	}
 
+	return output.SetupLog(os.Stderr, level, lf.format)
 }
 
-func isFlagPassed(name string) bool {
+func isFlagPassed(fs *flag.FlagSet, name string) bool {
 	found := false
-	flag.Visit(func(f *flag.Flag) {
+	fs.Visit(func(f *flag.Flag) {
 		if f.Name == name {
 			found = true
 		}
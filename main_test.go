@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testDump = "dbobject/testdata/dumps/pg_dump_16.sql"
+
+// Runs the program with given arguments, discarding its output
+func runQuiet(t *testing.T, args ...string) int {
+
+	t.Helper()
+
+	devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devnull.Close()
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = devnull, devnull
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	return run(args)
+}
+
+func TestRunDispatch(t *testing.T) {
+
+	dst := filepath.Join(t.TempDir(), "structure")
+	other := filepath.Join(t.TempDir(), "structure")
+
+	if err := os.MkdirAll(other, 0770); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want int
+	}{
+		{nil, exitUsage},
+		{[]string{"help"}, exitOk},
+		{[]string{"-h"}, exitOk},
+		{[]string{"diff", "-h"}, exitOk},
+
+		// split is the default command
+		{[]string{"-f", testDump, "-dst", dst, "-quiet"}, exitOk},
+		{[]string{"split", "-f", testDump, "-dst", dst, "-clean", "-quiet"}, exitOk},
+		{[]string{"split", "-mode", "bogus"}, exitUsage},
+		{[]string{"split", "-bogus"}, exitUsage},
+		{[]string{"split", "unexpected"}, exitUsage},
+		{[]string{"split", "-f", "missing.sql", "-dst", dst, "-quiet"}, exitError},
+
+		{[]string{"diff", dst, dst}, exitOk},
+		{[]string{"diff", "-format", "json", dst, other}, exitDifferences},
+		{[]string{"diff", dst, filepath.Join(other, "missing")}, exitError},
+		{[]string{"diff", "-format", "bogus", dst, dst}, exitUsage},
+		{[]string{"diff", dst}, exitUsage},
+
+		{[]string{"verify", "-f", testDump, "-dst", dst}, exitOk},
+		{[]string{"verify", "-f", testDump, "-dst", other}, exitDifferences},
+		{[]string{"verify", "-f", testDump, "-dst", dst, "-format", "bogus"}, exitUsage},
+		{[]string{"verify", "-f", testDump, "-dst", dst, "-dry-run"}, exitUsage},
+
+		{[]string{"ls", "-f", testDump}, exitOk},
+		{[]string{"ls", "-f", testDump, "-format", "bogus"}, exitUsage},
+		{[]string{"show", "-f", testDump, "billing.invoice"}, exitOk},
+		{[]string{"show", "-f", testDump, "billing.missing"}, exitError},
+		{[]string{"show", "-f", testDump}, exitUsage},
+
+		{[]string{"join", "-o", filepath.Join(t.TempDir(), "joined.sql"), dst}, exitOk},
+		{[]string{"join"}, exitUsage},
+	} {
+
+		if got := runQuiet(t, tc.args...); got != tc.want {
+			t.Errorf("%v: got exit code %d, wants %d", tc.args, got, tc.want)
+		}
+	}
+}
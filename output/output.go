@@ -1,9 +1,18 @@
 package output
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
 
 var Quiet bool
 
+// Leveled logger for diagnostic messages. They are written to stderr
+var Log = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
 func Println(a ...any) (n int, err error) {
 	if Quiet {
 		return -1, nil
@@ -11,3 +20,37 @@ func Println(a ...any) (n int, err error) {
 
 	return fmt.Println(a...)
 }
+
+// Sets up the logger.
+// Level is one of debug, info, warn, error. Format is either text or json
+func SetupLog(w io.Writer, level string, format string) error {
+
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level: %s", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case "", "text":
+		Log = slog.New(slog.NewTextHandler(w, opts))
+	case "json":
+		Log = slog.New(slog.NewJSONHandler(w, opts))
+	default:
+		return fmt.Errorf("invalid log format: %s", format)
+	}
+
+	return nil
+}
+
+// Checks whether the file (ie os.Stderr) is attached to a terminal
+func IsTerminal(f *os.File) bool {
+
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return (stat.Mode() & os.ModeCharDevice) != 0
+}